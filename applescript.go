@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// appleScriptDriver implements the tab operations shared by all browsers scriptable with AppleScript
+type appleScriptDriver struct {
+	app               *browserApplication
+	titleProperty     string // Scripting property of a tab containing its title
+	activeTabFunction string // JavaScript function of a window returning the index of its active tab
+	tabIDFunction     string // JavaScript function of a tab returning its identifier, empty if tabs have no identifier
+	verbose           bool
+}
+
+func (d *appleScriptDriver) ListTabs(window int, maxTabs int) ([]*tabInfo, error) {
+	tabs := []*tabInfo{}
+	err := d.StreamTabs(window, maxTabs, func(tab *tabInfo) error {
+		tabs = append(tabs, tab)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tabs, nil
+}
+
+func (d *appleScriptDriver) StreamTabs(window int, maxTabs int, tabF func(*tabInfo) error) error {
+	script, err := listTabsScript(d.app.cmdName, d.titleProperty, d.activeTabFunction, d.tabIDFunction, window, maxTabs)
+	if err != nil {
+		return err
+	}
+
+	var tabErr error // Distinguish errors returned by tabF from errors parsing tabs
+	err = execJXAScript(script, func(line []byte) error {
+		tab, err := parseTabInfo(line)
+		if err != nil {
+			return fmt.Errorf("failed to parse tab: %w", err)
+		}
+		tabErr = tabF(tab)
+		return tabErr
+	}, d.verbose)
+	if err != nil {
+		if tabErr != nil {
+			return tabErr
+		}
+		return fmt.Errorf("failed to get tabs: %w", err)
+	}
+	return nil
+}
+
+func (d *appleScriptDriver) CloseTabs(tabs []*tabInfo) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	for _, statement := range closeTabsStatements(tabs) {
+		err := execOsaScript(d.tellScript(statement), &stdout, &stderr, d.verbose)
+		if err != nil {
+			if errors.Is(err, errEndOfTabs) {
+				break
+			}
+			return fmt.Errorf("failed to close tab: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// closeTabsStatements returns the statements closing each tab of the active window, ordered from the
+// last tab to the first so that closing a tab by index does not shift the index of the remaining tabs.
+// Tabs are closed by identifier if supported so that tabs moved since listing are not closed.
+func closeTabsStatements(tabs []*tabInfo) []string {
+	statements := []string{}
+	for _, tab := range tabsFromEnd(tabs) {
+		if tab.ID != "" {
+			statements = append(statements, fmt.Sprintf("close (every tab of window 1 whose id is %s)", tab.ID))
+		} else {
+			statements = append(statements, fmt.Sprintf("close tab %d of window 1", tab.TabIndex))
+		}
+	}
+	return statements
+}
+
+func (d *appleScriptDriver) tellScript(statement string) string {
+	return "tell application \"" + d.app.cmdName + "\" to " + statement
+}
+
+// listTabsScript returns a JavaScript for Automation script that writes the identifier, URL, title and
+// position of each tab of the window at the provided index, or of every window if the index is allWindows, up
+// to maxTabs tabs unless maxTabs is noTabLimit, to stdout as a line of JSON. Each line is written as
+// soon as the tab is read so that output can be processed before every tab has been read. Each tab is
+// read in a single pass so that a URL and title cannot be paired across tabs, and non-ASCII characters
+// are escaped so that the output does not depend on the encoding osascript uses for stdout.
+func listTabsScript(appName string, titleProperty string, activeTabFunction string, tabIDFunction string, window int, maxTabs int) (string, error) {
+	// Encode values as JSON to produce valid JavaScript literals
+	name, err := json.Marshal(appName)
+	if err != nil {
+		return "", err
+	}
+	property, err := json.Marshal(titleProperty)
+	if err != nil {
+		return "", err
+	}
+	if tabIDFunction == "" {
+		tabIDFunction = `() => ""`
+	}
+
+	return fmt.Sprintf(`ObjC.import("Foundation");
+(() => {
+	const app = Application(%s);
+	const activeTab = %s;
+	const titleProperty = %s;
+	const tabID = %s;
+	const selectedWindow = %d; // All windows if 0
+	const maxTabs = %d; // No limit if 0
+
+	const stdout = $.NSFileHandle.fileHandleWithStandardOutput;
+	let count = 0;
+	const write = (record) => {
+		const line = JSON.stringify(record).replace(/[\u007f-\uffff]/g, (c) => "\\u" + c.charCodeAt(0).toString(16).padStart(4, "0"));
+		stdout.writeData($(line + "\n").dataUsingEncoding($.NSUTF8StringEncoding));
+		count++;
+	};
+	const limitReached = () => maxTabs !== 0 && count >= maxTabs;
+
+	const windows = app.windows();
+	for (let w = 0; w < windows.length && !limitReached(); w++) {
+		if (selectedWindow !== 0 && selectedWindow !== w + 1) {
+			continue;
+		}
+		let tabs, active;
+		try {
+			tabs = windows[w].tabs();
+			active = activeTab(windows[w]);
+		} catch (e) {
+			continue; // Skip windows without tabs
+		}
+		for (let t = 0; t < tabs.length && !limitReached(); t++) {
+			write({
+				id: String(tabID(tabs[t])),
+				url: tabs[t].url() || "",
+				name: tabs[t][titleProperty]() || "",
+				window: w + 1,
+				tab: t + 1,
+				active: active === t + 1,
+			});
+		}
+	}
+	return "";
+})()`, name, activeTabFunction, property, tabIDFunction, window, maxTabs), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListTabsScript(tt *testing.T) {
+	tests := map[string]struct {
+		appName           string
+		titleProperty     string
+		activeTabFunction string
+		tabIDFunction     string
+		window            int
+		maxTabs           int
+		expected          []string
+	}{
+		"chromium browser": {
+			appName:           "Google Chrome",
+			titleProperty:     "title",
+			activeTabFunction: "(window) => window.activeTabIndex()",
+			tabIDFunction:     "(tab) => tab.id()",
+			window:            activeWindow,
+			maxTabs:           100,
+			expected: []string{
+				`const app = Application("Google Chrome");`,
+				`const activeTab = (window) => window.activeTabIndex();`,
+				`const tabID = (tab) => tab.id();`,
+				`const titleProperty = "title";`,
+				`const selectedWindow = 1;`,
+				`const maxTabs = 100;`,
+			},
+		},
+		"safari with all windows and max tabs": {
+			appName:           "Safari",
+			titleProperty:     "name",
+			activeTabFunction: "(window) => window.currentTab().index()",
+			window:            allWindows,
+			maxTabs:           5,
+			expected: []string{
+				`const app = Application("Safari");`,
+				`const activeTab = (window) => window.currentTab().index();`,
+				`const tabID = () => "";`,
+				`const titleProperty = "name";`,
+				`const selectedWindow = 0;`,
+				`const maxTabs = 5;`,
+			},
+		},
+		"application name is escaped": {
+			appName:           `My "Browser"`,
+			titleProperty:     "title",
+			activeTabFunction: "(window) => window.activeTabIndex()",
+			window:            activeWindow,
+			maxTabs:           1,
+			expected: []string{
+				`Application("My \"Browser\"")`,
+				`const activeTab = (window) => window.activeTabIndex();`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			script, err := listTabsScript(test.appName, test.titleProperty, test.activeTabFunction, test.tabIDFunction, test.window, test.maxTabs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(script, expected) {
+					t.Errorf("expected script to contain %q, script:\n%s", expected, script)
+				}
+			}
+		})
+	}
+}
+
+func TestCloseTabsStatements(tt *testing.T) {
+	tests := map[string]struct {
+		tabs     []*tabInfo
+		expected []string
+	}{
+		"adjacent tabs by index": {
+			tabs: []*tabInfo{{TabIndex: 2}, {TabIndex: 3}, {TabIndex: 4}},
+			expected: []string{
+				"close tab 4 of window 1",
+				"close tab 3 of window 1",
+				"close tab 2 of window 1",
+			},
+		},
+		"adjacent tabs by id": {
+			tabs: []*tabInfo{{ID: "101", TabIndex: 2}, {ID: "102", TabIndex: 3}, {ID: "103", TabIndex: 4}},
+			expected: []string{
+				"close (every tab of window 1 whose id is 103)",
+				"close (every tab of window 1 whose id is 102)",
+				"close (every tab of window 1 whose id is 101)",
+			},
+		},
+		"tabs listed out of order": {
+			tabs: []*tabInfo{{TabIndex: 3}, {TabIndex: 1}, {TabIndex: 2}},
+			expected: []string{
+				"close tab 3 of window 1",
+				"close tab 2 of window 1",
+				"close tab 1 of window 1",
+			},
+		},
+		"no tabs": {
+			tabs:     []*tabInfo{},
+			expected: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result := closeTabsStatements(test.tabs)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// bookmarksTabEncoder writes tabs as a Netscape bookmark file, the format that browsers use to import
+// and export bookmarks, with the tabs of a grab in a single folder and each window in a subfolder if
+// windowFolders is true
+type bookmarksTabEncoder struct {
+	w             io.Writer
+	title         string    // Title of the folder containing the grabbed tabs
+	date          time.Time // Date the tabs were grabbed
+	windowFolders bool
+	started       bool
+	prevWindow    int
+}
+
+func newBookmarksTabEncoder(w io.Writer, windowFolders bool) *bookmarksTabEncoder {
+	now := time.Now()
+	return &bookmarksTabEncoder{
+		w:             w,
+		title:         fmt.Sprintf("%s %s", appName, now.Format("2006-01-02 15:04:05")),
+		date:          now,
+		windowFolders: windowFolders,
+	}
+}
+
+const bookmarksHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+
+func (e *bookmarksTabEncoder) Encode(tab *tabInfo) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.windowFolders && tab.WindowIndex != e.prevWindow {
+		if e.prevWindow != 0 {
+			if _, err := io.WriteString(e.w, "        </DL><p>\n"); err != nil {
+				return err
+			}
+		}
+		if err := e.writeFolder("        ", fmt.Sprintf("Window %d", tab.WindowIndex)); err != nil {
+			return err
+		}
+	}
+	e.prevWindow = tab.WindowIndex
+
+	indent := "        "
+	if e.windowFolders {
+		indent += "    "
+	}
+	// Title the link with its URL if the tab has no name as bookmarks require a title
+	name := tab.Name
+	if name == "" {
+		name = tab.URL
+	}
+	_, err := fmt.Fprintf(e.w, "%s<DT><A HREF=\"%s\" ADD_DATE=\"%d\">%s</A>\n",
+		indent, html.EscapeString(tab.URL), e.date.Unix(), html.EscapeString(name))
+	return err
+}
+
+func (e *bookmarksTabEncoder) Finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	end := "    </DL><p>\n</DL><p>\n"
+	if e.windowFolders && e.prevWindow != 0 {
+		end = "        </DL><p>\n" + end
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// start writes the document header and opens the folder of the grab before the first tab
+func (e *bookmarksTabEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if _, err := io.WriteString(e.w, bookmarksHeader); err != nil {
+		return err
+	}
+	return e.writeFolder("    ", e.title)
+}
+
+func (e *bookmarksTabEncoder) writeFolder(indent string, title string) error {
+	_, err := fmt.Fprintf(e.w, "%s<DT><H3 ADD_DATE=\"%d\">%s</H3>\n%s<DL><p>\n",
+		indent, e.date.Unix(), html.EscapeString(title), indent)
+	return err
+}
+
+var (
+	// Tags that structure a bookmark file, with the text following the tag
+	bookmarksTagRegexp = regexp.MustCompile(`(?is)<(/?)(dl|h3|a)\b([^>]*)>([^<]*)`)
+	// HREF attribute of a link, quoted or unquoted
+	bookmarksHrefRegexp = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// readBookmarkTabs reads the links of a Netscape bookmark file as tabs, limited to the links within
+// folders with the provided name if it is not empty. The links of each folder are assigned the same
+// window so that each folder is opened in its own window.
+func readBookmarkTabs(r io.ReadCloser, folder string) ([]*tabInfo, error) {
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks from reader: %w", err)
+	}
+
+	type bookmarkFolder struct {
+		id       int
+		selected bool // Whether the folder is, or is within, the provided folder
+	}
+
+	tabs := []*tabInfo{}
+	folderCount := 0
+	foundFolder := folder == ""
+	stack := []bookmarkFolder{{selected: folder == ""}} // Links outside of any list are in the root
+	pendingTitle := ""                                  // Title of the most recent folder heading
+
+	for _, match := range bookmarksTagRegexp.FindAllStringSubmatch(string(data), -1) {
+		closing, tag, attrs, text := match[1] == "/", strings.ToLower(match[2]), match[3], match[4]
+		current := stack[len(stack)-1]
+
+		switch {
+		case tag == "h3" && !closing:
+			pendingTitle = html.UnescapeString(strings.TrimSpace(text))
+		case tag == "dl" && !closing:
+			folderCount++
+			selected := current.selected || (folder != "" && pendingTitle == folder)
+			foundFolder = foundFolder || selected
+			stack = append(stack, bookmarkFolder{id: folderCount, selected: selected})
+			pendingTitle = ""
+		case tag == "dl" && closing:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case tag == "a" && !closing:
+			href := bookmarksHrefRegexp.FindStringSubmatch(attrs)
+			if href == nil || !current.selected {
+				continue
+			}
+			tabs = append(tabs, &tabInfo{
+				URL:         html.UnescapeString(strings.TrimSpace(href[1] + href[2] + href[3])),
+				Name:        html.UnescapeString(strings.TrimSpace(text)),
+				WindowIndex: current.id,
+			})
+		}
+	}
+
+	if !foundFolder {
+		return nil, fmt.Errorf("bookmark folder %q not found", folder)
+	}
+	return tabs, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookmarksTabEncoder(tt *testing.T) {
+	tabs := []*tabInfo{
+		{URL: "https://foo.com/?a=1&b=2", Name: `Foo <"Bar">`, WindowIndex: 1},
+		{URL: "https://bar.com", WindowIndex: 1},
+		{URL: "https://baz.com", Name: "Baz", WindowIndex: 2},
+	}
+
+	tests := map[string]struct {
+		tabs          []*tabInfo
+		windowFolders bool
+		expected      string
+	}{
+		"single folder": {
+			tabs: tabs,
+			expected: bookmarksHeader +
+				"    <DT><H3 ADD_DATE=\"1700000000\">tabgrab test</H3>\n" +
+				"    <DL><p>\n" +
+				"        <DT><A HREF=\"https://foo.com/?a=1&amp;b=2\" ADD_DATE=\"1700000000\">Foo &lt;&#34;Bar&#34;&gt;</A>\n" +
+				"        <DT><A HREF=\"https://bar.com\" ADD_DATE=\"1700000000\">https://bar.com</A>\n" +
+				"        <DT><A HREF=\"https://baz.com\" ADD_DATE=\"1700000000\">Baz</A>\n" +
+				"    </DL><p>\n" +
+				"</DL><p>\n",
+		},
+		"window folders": {
+			tabs:          tabs,
+			windowFolders: true,
+			expected: bookmarksHeader +
+				"    <DT><H3 ADD_DATE=\"1700000000\">tabgrab test</H3>\n" +
+				"    <DL><p>\n" +
+				"        <DT><H3 ADD_DATE=\"1700000000\">Window 1</H3>\n" +
+				"        <DL><p>\n" +
+				"            <DT><A HREF=\"https://foo.com/?a=1&amp;b=2\" ADD_DATE=\"1700000000\">Foo &lt;&#34;Bar&#34;&gt;</A>\n" +
+				"            <DT><A HREF=\"https://bar.com\" ADD_DATE=\"1700000000\">https://bar.com</A>\n" +
+				"        </DL><p>\n" +
+				"        <DT><H3 ADD_DATE=\"1700000000\">Window 2</H3>\n" +
+				"        <DL><p>\n" +
+				"            <DT><A HREF=\"https://baz.com\" ADD_DATE=\"1700000000\">Baz</A>\n" +
+				"        </DL><p>\n" +
+				"    </DL><p>\n" +
+				"</DL><p>\n",
+		},
+		"no tabs": {
+			tabs:          []*tabInfo{},
+			windowFolders: true,
+			expected: bookmarksHeader +
+				"    <DT><H3 ADD_DATE=\"1700000000\">tabgrab test</H3>\n" +
+				"    <DL><p>\n" +
+				"    </DL><p>\n" +
+				"</DL><p>\n",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			e := &bookmarksTabEncoder{
+				w:             buf,
+				title:         "tabgrab test",
+				date:          time.Unix(1700000000, 0),
+				windowFolders: test.windowFolders,
+			}
+			for _, tab := range test.tabs {
+				if err := e.Encode(tab); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if err := e.Finish(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := buf.String(); result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
+
+// Bookmark file in the style exported by browsers
+const testBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://foo.com/?a=1&amp;b=2" ADD_DATE="1700000000" ICON="data:image/png;base64,AAAA">Foo &amp; Bar</A>
+        <DT><H3>Reading</H3>
+        <DL><p>
+            <DT><a href='https://bar.com'>Bar</a>
+            <DD>Description of bar
+            <DT><H3>Later</H3>
+            <DL><p>
+                <DT><A HREF=https://baz.com>Baz</A>
+            </DL><p>
+        </DL><p>
+        <DT><A HREF="https://qux.com">Qux</A>
+    </DL><p>
+    <DT><H3>Other</H3>
+    <DL><p>
+        <DT><A HREF="https://other.com">Other</A>
+        <HR>
+    </DL><p>
+</DL><p>
+`
+
+func TestReadBookmarkTabs(tt *testing.T) {
+	tests := map[string]struct {
+		input       string
+		folder      string
+		expected    [][]string
+		expectedErr bool
+	}{
+		"all folders": {
+			input:  testBookmarks,
+			folder: "",
+			expected: [][]string{
+				{"https://foo.com/?a=1&b=2", "https://qux.com"},
+				{"https://bar.com"},
+				{"https://baz.com"},
+				{"https://other.com"},
+			},
+		},
+		"folder with subfolders": {
+			input:    testBookmarks,
+			folder:   "Reading",
+			expected: [][]string{{"https://bar.com"}, {"https://baz.com"}},
+		},
+		"folder without subfolders": {
+			input:    testBookmarks,
+			folder:   "Other",
+			expected: [][]string{{"https://other.com"}},
+		},
+		"folder not found": {
+			input:       testBookmarks,
+			folder:      "Missing",
+			expectedErr: true,
+		},
+		"empty input": {
+			input:    "",
+			expected: [][]string{},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			r := &urlReadCloser{
+				Reader: strings.NewReader(test.input),
+				Closer: func() error { return nil },
+			}
+			tabs, err := readBookmarkTabs(r, test.folder)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := groupWindowURLs(tabs); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestBookmarksRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver(fakeTabs("https://foo.com/?a=1&b=2", "https://bar.com"), fakeTabs("https://baz.com")),
+			maxTabs: noTabLimit,
+		},
+		urlWriter: newTestWriter(buf),
+		format:    formatHTMLBookmarks,
+		template:  templateURL,
+		window:    allWindows,
+	}
+	if err := grabTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &urlReadCloser{
+		Reader: buf,
+		Closer: func() error { return nil },
+	}
+	tabs, err := readBookmarkTabs(r, "Window 1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []*tabInfo{
+		{URL: "https://foo.com/?a=1&b=2", Name: "tab 1", WindowIndex: 3},
+		{URL: "https://bar.com", Name: "tab 2", WindowIndex: 3},
+	}
+	if !reflect.DeepEqual(tabs, expected) {
+		t.Errorf("expected %v, result %v", expected, tabs)
+	}
+}
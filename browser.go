@@ -2,38 +2,56 @@ package main
 
 const (
 	// Browser names
-	browserNameAtlas  = "atlas"
-	browserNameBrave  = "brave"
-	browserNameChrome = "chrome"
-	browserNameComet  = "comet"
-	browserNameSafari = "safari"
+	browserNameAtlas     = "atlas"
+	browserNameBrave     = "brave"
+	browserNameChrome    = "chrome"
+	browserNameChromeCDP = "chrome-cdp"
+	browserNameComet     = "comet"
+	browserNameFirefox   = "firefox"
+	browserNameSafari    = "safari"
 )
 
 // Browser applications by name
 var browserApplications = map[string]*browserApplication{
 	browserNameAtlas: {
-		name:    browserNameAtlas,
-		cmdName: "ChatGPT Atlas",
+		name:      browserNameAtlas,
+		cmdName:   "ChatGPT Atlas",
+		newDriver: newChromiumDriver,
 	},
 	browserNameBrave: {
-		name:    browserNameBrave,
-		cmdName: "Brave Browser",
+		name:      browserNameBrave,
+		cmdName:   "Brave Browser",
+		newDriver: newChromiumDriver,
 	},
 	browserNameChrome: {
-		name:    browserNameChrome,
-		cmdName: "Google Chrome",
+		name:      browserNameChrome,
+		cmdName:   "Google Chrome",
+		newDriver: newChromiumDriver,
+	},
+	browserNameChromeCDP: {
+		name:      browserNameChromeCDP,
+		cmdName:   "Google Chrome",
+		newDriver: newCDPDriver,
 	},
 	browserNameComet: {
-		name:    browserNameComet,
-		cmdName: "Comet",
+		name:      browserNameComet,
+		cmdName:   "Comet",
+		newDriver: newChromiumDriver,
+	},
+	browserNameFirefox: {
+		name:      browserNameFirefox,
+		cmdName:   "Firefox",
+		newDriver: newFirefoxDriver,
 	},
 	browserNameSafari: {
-		name:    browserNameSafari,
-		cmdName: "Safari",
+		name:      browserNameSafari,
+		cmdName:   "Safari",
+		newDriver: newSafariDriver,
 	},
 }
 
 type browserApplication struct {
-	name    string
-	cmdName string
+	name      string
+	cmdName   string
+	newDriver func(app *browserApplication, cfg *driverConfig) browserDriver
 }
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const cdpTimeout = 10 * time.Second
+
+// cdpDriver implements tab operations using the Chrome DevTools Protocol of a Chromium browser
+// started with the --remote-debugging-port flag
+type cdpDriver struct {
+	address string
+	client  *http.Client
+	verbose bool
+}
+
+func newCDPDriver(app *browserApplication, cfg *driverConfig) browserDriver {
+	return &cdpDriver{
+		address: cfg.cdpAddress,
+		client:  &http.Client{Timeout: cdpTimeout},
+		verbose: cfg.verbose,
+	}
+}
+
+type cdpTarget struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+func (d *cdpDriver) ListTabs(window int, maxTabs int) ([]*tabInfo, error) {
+	windows, err := d.windows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tabs: %w", err)
+	}
+
+	// The DevTools Protocol lists pages ordered by recency rather than position and does not report
+	// which tab is active, so the tab index of each tab is its recency order within its window and no tab
+	// is marked as active
+	windowTabs := [][]*tabInfo{}
+	for _, targets := range windows {
+		tabs := []*tabInfo{}
+		for _, target := range targets {
+			tabs = append(tabs, &tabInfo{
+				ID:   target.ID,
+				URL:  target.URL,
+				Name: target.Title,
+			})
+		}
+		windowTabs = append(windowTabs, tabs)
+	}
+	return selectWindowTabs(windowTabs, window, maxTabs), nil
+}
+
+func (d *cdpDriver) OpenTabs(urls []string, browserArgs string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	if browserArgs != "" && d.verbose {
+		log.Printf("ignoring browser arguments for running browser: %s\n", browserArgs)
+	}
+
+	session, err := d.browserSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// Open the first URL in a new window
+	err = session.call("Target.createTarget", map[string]any{"url": urls[0], "newWindow": true}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open window: %w", err)
+	}
+
+	// Open the remaining URLs as tabs within the window
+	for _, u := range urls[1:] {
+		query := strings.ReplaceAll(url.QueryEscape(u), "+", "%20")
+		if err := d.request(http.MethodPut, "/json/new?"+query, nil); err != nil {
+			return fmt.Errorf("failed to open tab: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *cdpDriver) CloseTabs(tabs []*tabInfo) error {
+	for _, tab := range tabs {
+		if tab.ID == "" {
+			return fmt.Errorf("tab %d of window 1 has no target id", tab.TabIndex)
+		}
+		if err := d.request(http.MethodGet, "/json/close/"+tab.ID, nil); err != nil {
+			return fmt.Errorf("failed to close tab: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *cdpDriver) ActivateTab(index int) error {
+	targets, err := d.activeWindowTargets()
+	if err != nil {
+		return fmt.Errorf("failed to get tabs: %w", err)
+	}
+	if index < 1 || index > len(targets) {
+		return fmt.Errorf("tab %d of window 1 not found", index)
+	}
+
+	if err := d.request(http.MethodGet, "/json/activate/"+targets[index-1].ID, nil); err != nil {
+		return fmt.Errorf("failed to activate tab: %w", err)
+	}
+	return nil
+}
+
+// windows returns the page targets grouped by window, with windows and pages ordered by most recent
+// activity since the DevTools Protocol does not expose the order of tabs within a window
+func (d *cdpDriver) windows() ([][]*cdpTarget, error) {
+	targets := []*cdpTarget{}
+	if err := d.request(http.MethodGet, "/json/list", &targets); err != nil {
+		return nil, err
+	}
+
+	pages := []*cdpTarget{}
+	for _, target := range targets {
+		if target.Type == "page" {
+			pages = append(pages, target)
+		}
+	}
+	if len(pages) == 0 {
+		return [][]*cdpTarget{}, nil
+	}
+
+	session, err := d.browserSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	windows := [][]*cdpTarget{}
+	windowPositions := map[int]int{} // Position in windows by window ID
+	for _, page := range pages {
+		result := struct {
+			WindowID int `json:"windowId"`
+		}{}
+		err := session.call("Browser.getWindowForTarget", map[string]any{"targetId": page.ID}, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get window for tab: %w", err)
+		}
+		pos, found := windowPositions[result.WindowID]
+		if !found {
+			pos = len(windows)
+			windowPositions[result.WindowID] = pos
+			windows = append(windows, []*cdpTarget{})
+		}
+		windows[pos] = append(windows[pos], page)
+	}
+	return windows, nil
+}
+
+// activeWindowTargets returns the page targets of the window containing the most recently active page
+func (d *cdpDriver) activeWindowTargets() ([]*cdpTarget, error) {
+	windows, err := d.windows()
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return []*cdpTarget{}, nil
+	}
+	return windows[0], nil
+}
+
+func (d *cdpDriver) browserSession() (*cdpSession, error) {
+	version := struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}{}
+	if err := d.request(http.MethodGet, "/json/version", &version); err != nil {
+		return nil, err
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return nil, errors.New("browser websocket debugger URL not found")
+	}
+
+	if d.verbose {
+		log.Printf("connecting: %s\n", version.WebSocketDebuggerURL)
+	}
+	conn, err := dialWebsocket(version.WebSocketDebuggerURL, cdpTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	return &cdpSession{conn: conn, verbose: d.verbose}, nil
+}
+
+func (d *cdpDriver) request(method string, path string, out any) error {
+	req, err := http.NewRequest(method, "http://"+d.address+path, nil)
+	if err != nil {
+		return err
+	}
+	if d.verbose {
+		log.Printf("requesting: %s %s\n", req.Method, req.URL)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s returned %s: %s", req.Method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// cdpSession issues DevTools Protocol commands over a browser websocket connection
+type cdpSession struct {
+	conn    *websocketConn
+	nextID  int
+	verbose bool
+}
+
+type cdpMessage struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params any             `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (s *cdpSession) call(method string, params any, result any) error {
+	s.nextID++
+	msg, err := json.Marshal(&cdpMessage{ID: s.nextID, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if s.verbose {
+		log.Printf("sending: %s\n", msg)
+	}
+	if err := s.conn.WriteMessage(msg); err != nil {
+		return err
+	}
+
+	for {
+		raw, err := s.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		resp := &cdpMessage{}
+		if err := json.Unmarshal(raw, resp); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if resp.ID != s.nextID {
+			continue // Skip events and responses to other commands
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%s failed: %s (%d)", method, resp.Error.Message, resp.Error.Code)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+func (s *cdpSession) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCDPTarget is a page target of the fake DevTools endpoint
+type fakeCDPTarget struct {
+	cdpTarget
+	windowID int
+}
+
+// fakeCDPBrowser mimics the HTTP and websocket endpoints of a browser started with --remote-debugging-port
+type fakeCDPBrowser struct {
+	mu           sync.Mutex
+	targets      []*fakeCDPTarget // Ordered by most recent activity
+	nextID       int
+	nextWindowID int
+	server       *httptest.Server
+}
+
+func newFakeCDPBrowser(windows ...[]string) *fakeCDPBrowser {
+	b := &fakeCDPBrowser{nextWindowID: len(windows)}
+	// Add pages in reverse so that the first page of the first window is the most recently active
+	for i := len(windows) - 1; i >= 0; i-- {
+		for j := len(windows[i]) - 1; j >= 0; j-- {
+			b.addTarget(windows[i][j], i+1)
+		}
+	}
+	// Include a non-page target that must be ignored
+	b.targets = append(b.targets, &fakeCDPTarget{cdpTarget: cdpTarget{ID: "worker", Type: "service_worker", URL: "https://worker.com"}})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/list", b.handleList)
+	mux.HandleFunc("/json/version", b.handleVersion)
+	mux.HandleFunc("/json/new", b.handleNew)
+	mux.HandleFunc("/json/close/", b.handleClose)
+	mux.HandleFunc("/json/activate/", b.handleActivate)
+	mux.HandleFunc("/devtools/browser/fake", b.handleWebsocket)
+	b.server = httptest.NewServer(mux)
+	return b
+}
+
+func (b *fakeCDPBrowser) driver() *cdpDriver {
+	return newCDPDriver(browserApplications[browserNameChromeCDP], &driverConfig{
+		cdpAddress: strings.TrimPrefix(b.server.URL, "http://"),
+	}).(*cdpDriver)
+}
+
+// addTarget adds a page as the most recently active target
+func (b *fakeCDPBrowser) addTarget(u string, windowID int) *fakeCDPTarget {
+	b.nextID++
+	target := &fakeCDPTarget{
+		cdpTarget: cdpTarget{ID: fmt.Sprintf("T%d", b.nextID), Type: "page", Title: "title " + u, URL: u},
+		windowID:  windowID,
+	}
+	b.targets = append([]*fakeCDPTarget{target}, b.targets...)
+	return target
+}
+
+func (b *fakeCDPBrowser) find(id string) int {
+	for i, target := range b.targets {
+		if target.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// windowURLs returns the URLs of the pages of a window in order of most recent activity
+func (b *fakeCDPBrowser) windowURLs(windowID int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	urls := []string{}
+	for _, target := range b.targets {
+		if target.Type == "page" && target.windowID == windowID {
+			urls = append(urls, target.URL)
+		}
+	}
+	return urls
+}
+
+func (b *fakeCDPBrowser) handleList(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	targets := []cdpTarget{}
+	for _, target := range b.targets {
+		targets = append(targets, target.cdpTarget)
+	}
+	_ = json.NewEncoder(w).Encode(targets)
+}
+
+func (b *fakeCDPBrowser) handleVersion(w http.ResponseWriter, r *http.Request) {
+	wsURL := "ws://" + r.Host + "/devtools/browser/fake"
+	_ = json.NewEncoder(w).Encode(map[string]string{"webSocketDebuggerUrl": wsURL})
+}
+
+func (b *fakeCDPBrowser) handleNew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Using unsafe HTTP verb GET to invoke /json/new", http.StatusMethodNotAllowed)
+		return
+	}
+	u, err := url.QueryUnescape(r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	target := b.addTarget(u, b.nextWindowID)
+	_ = json.NewEncoder(w).Encode(target.cdpTarget)
+}
+
+func (b *fakeCDPBrowser) handleClose(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := b.find(strings.TrimPrefix(r.URL.Path, "/json/close/"))
+	if i < 0 {
+		http.Error(w, "No such target id", http.StatusNotFound)
+		return
+	}
+	b.targets = append(b.targets[:i], b.targets[i+1:]...)
+	fmt.Fprint(w, "Target is closing")
+}
+
+func (b *fakeCDPBrowser) handleActivate(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := b.find(strings.TrimPrefix(r.URL.Path, "/json/activate/"))
+	if i < 0 {
+		http.Error(w, "No such target id", http.StatusNotFound)
+		return
+	}
+	target := b.targets[i]
+	b.targets = append([]*fakeCDPTarget{target}, append(b.targets[:i], b.targets[i+1:]...)...)
+	fmt.Fprint(w, "Target activated")
+}
+
+func (b *fakeCDPBrowser) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	accept := websocketAccept(r.Header.Get("Sec-WebSocket-Key"))
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	for {
+		_, opcode, payload, err := readWebsocketFrame(rw.Reader)
+		if err != nil || opcode == wsOpClose {
+			return
+		}
+		resp, err := json.Marshal(b.handleCommand(payload))
+		if err != nil {
+			return
+		}
+		// Send an unrelated event before each response
+		_ = writeWebsocketFrame(rw.Writer, wsOpText, []byte(`{"method":"Target.targetInfoChanged","params":{}}`), false)
+		if err := writeWebsocketFrame(rw.Writer, wsOpText, resp, false); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeCDPBrowser) handleCommand(payload []byte) map[string]any {
+	cmd := struct {
+		ID     int            `json:"id"`
+		Method string         `json:"method"`
+		Params map[string]any `json:"params"`
+	}{}
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return map[string]any{"id": 0, "error": map[string]any{"code": -32700, "message": err.Error()}}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch cmd.Method {
+	case "Browser.getWindowForTarget":
+		i := b.find(fmt.Sprint(cmd.Params["targetId"]))
+		if i < 0 {
+			return map[string]any{"id": cmd.ID, "error": map[string]any{"code": -32000, "message": "No target with given id found"}}
+		}
+		return map[string]any{"id": cmd.ID, "result": map[string]any{"windowId": b.targets[i].windowID}}
+	case "Target.createTarget":
+		if cmd.Params["newWindow"] == true {
+			b.nextWindowID++
+		}
+		target := b.addTarget(fmt.Sprint(cmd.Params["url"]), b.nextWindowID)
+		return map[string]any{"id": cmd.ID, "result": map[string]any{"targetId": target.ID}}
+	default:
+		return map[string]any{"id": cmd.ID, "error": map[string]any{"code": -32601, "message": "method not found"}}
+	}
+}
+
+func TestCDPDriverListTabs(tt *testing.T) {
+	tests := map[string]struct {
+		window   int
+		maxTabs  int
+		expected []*tabInfo
+	}{
+		"tabs of the active window": {
+			window:  activeWindow,
+			maxTabs: 100,
+			expected: []*tabInfo{
+				{ID: "T3", URL: "https://foo.com", Name: "title https://foo.com", WindowIndex: 1, TabIndex: 1},
+				{ID: "T2", URL: "https://bar.com", Name: "title https://bar.com", WindowIndex: 1, TabIndex: 2},
+			},
+		},
+		"tabs of all windows": {
+			window:  allWindows,
+			maxTabs: 100,
+			expected: []*tabInfo{
+				{ID: "T3", URL: "https://foo.com", Name: "title https://foo.com", WindowIndex: 1, TabIndex: 1},
+				{ID: "T2", URL: "https://bar.com", Name: "title https://bar.com", WindowIndex: 1, TabIndex: 2},
+				{ID: "T1", URL: "https://other.com", Name: "title https://other.com", WindowIndex: 2, TabIndex: 1},
+			},
+		},
+		"tabs of the second window": {
+			window:  2,
+			maxTabs: 100,
+			expected: []*tabInfo{
+				{ID: "T1", URL: "https://other.com", Name: "title https://other.com", WindowIndex: 2, TabIndex: 1},
+			},
+		},
+		"max tabs less than number of tabs": {
+			window:  allWindows,
+			maxTabs: 1,
+			expected: []*tabInfo{
+				{ID: "T3", URL: "https://foo.com", Name: "title https://foo.com", WindowIndex: 1, TabIndex: 1},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			browser := newFakeCDPBrowser(
+				[]string{"https://foo.com", "https://bar.com"},
+				[]string{"https://other.com"},
+			)
+			defer browser.server.Close()
+
+			result, err := browser.driver().ListTabs(test.window, test.maxTabs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCDPDriverOpenTabs(t *testing.T) {
+	browser := newFakeCDPBrowser([]string{"https://existing.com"})
+	defer browser.server.Close()
+
+	urls := []string{"https://foo.com", "https://bar.com/search?q=a b&x=1", "https://baz.com"}
+	if err := browser.driver().OpenTabs(urls, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://baz.com", "https://bar.com/search?q=a b&x=1", "https://foo.com"}
+	if result := browser.windowURLs(2); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+	if result := browser.windowURLs(1); !reflect.DeepEqual(result, []string{"https://existing.com"}) {
+		t.Errorf("expected existing window to be unchanged, result %v", result)
+	}
+}
+
+func TestCDPDriverCloseTabs(tt *testing.T) {
+	tests := map[string]struct {
+		indices     []int // 1-based indices of the listed tabs to close
+		expected    []string
+		expectedErr bool
+	}{
+		"single tab": {
+			indices:  []int{2},
+			expected: []string{"https://foo.com", "https://baz.com"},
+		},
+		"adjacent tabs": {
+			indices:  []int{1, 2},
+			expected: []string{"https://baz.com"},
+		},
+		"every tab": {
+			indices:  []int{1, 2, 3},
+			expected: []string{},
+		},
+		"tab already closed": {
+			indices:     []int{3, 3},
+			expected:    []string{"https://foo.com", "https://bar.com"},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			browser := newFakeCDPBrowser(
+				[]string{"https://foo.com", "https://bar.com", "https://baz.com"},
+				[]string{"https://other.com"},
+			)
+			defer browser.server.Close()
+
+			driver := browser.driver()
+			listed, err := driver.ListTabs(activeWindow, noTabLimit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tabs := []*tabInfo{}
+			for _, idx := range test.indices {
+				tabs = append(tabs, listed[idx-1])
+			}
+
+			err = driver.CloseTabs(tabs)
+			if test.expectedErr && err == nil {
+				t.Fatal("expected error")
+			}
+			if !test.expectedErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := browser.windowURLs(1); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+			if result := browser.windowURLs(2); !reflect.DeepEqual(result, []string{"https://other.com"}) {
+				t.Errorf("expected other window to be unchanged, result %v", result)
+			}
+		})
+	}
+}
+
+func TestCDPDriverActivateTab(t *testing.T) {
+	browser := newFakeCDPBrowser([]string{"https://foo.com", "https://bar.com"})
+	defer browser.server.Close()
+
+	driver := browser.driver()
+	if err := driver.ActivateTab(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"https://bar.com", "https://foo.com"}
+	if result := browser.windowURLs(1); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+
+	if err := driver.ActivateTab(3); err == nil {
+		t.Error("expected error for tab index out of range")
+	}
+}
+
+func TestWebsocketFrameRoundTrip(tt *testing.T) {
+	tests := map[string]int{
+		"short payload":  10,
+		"medium payload": 300,
+		"long payload":   70000,
+	}
+
+	for name, size := range tests {
+		tt.Run(name, func(t *testing.T) {
+			payload := []byte(strings.Repeat("x", size))
+			buf := &strings.Builder{}
+			w := bufio.NewWriter(buf)
+			if err := writeWebsocketFrame(w, wsOpText, payload, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			fin, opcode, result, err := readWebsocketFrame(bufio.NewReader(strings.NewReader(buf.String())))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !fin || opcode != wsOpText || string(result) != string(payload) {
+				t.Errorf("frame did not round trip: fin %t, opcode %d, length %d", fin, opcode, len(result))
+			}
+		})
+	}
+}
+
+func TestReadWebsocketFrameTooLarge(tt *testing.T) {
+	tests := map[string][]byte{
+		"maximum 64-bit length": {0x81, 127, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"just over maximum":     {0x81, 127, 0, 0, 0, 0, 0x04, 0, 0, 0x01},
+	}
+
+	for name, header := range tests {
+		tt.Run(name, func(t *testing.T) {
+			_, _, _, err := readWebsocketFrame(bufio.NewReader(bytes.NewReader(header)))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
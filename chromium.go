@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// chromiumDriver implements tab operations for Chromium-based browsers
+type chromiumDriver struct {
+	*appleScriptDriver
+}
+
+func newChromiumDriver(app *browserApplication, cfg *driverConfig) browserDriver {
+	driver := &chromiumDriver{
+		appleScriptDriver: &appleScriptDriver{
+			app:               app,
+			titleProperty:     "title",
+			activeTabFunction: "(window) => window.activeTabIndex()",
+			tabIDFunction:     "(tab) => tab.id()",
+			verbose:           cfg.verbose,
+		},
+	}
+	if cfg.profileDir != "" {
+		return newSessionFileDriver(cfg.profileDir, driver, cfg.verbose)
+	}
+	return driver
+}
+
+func (d *chromiumDriver) OpenTabs(urls []string, browserArgs string) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	newWindowArg := "--new-window" // Open the first URL in a new window
+	for _, url := range urls {
+		cmd := exec.Command("open", "-na", d.app.cmdName, "--args", newWindowArg, browserArgs, url)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if d.verbose {
+			log.Printf("executing: %s\n", cmd.String())
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+		}
+		newWindowArg = "" // Open the remaining URLs as tabs within the window
+	}
+
+	return nil
+}
+
+func (d *chromiumDriver) ActivateTab(index int) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	script := d.tellScript(fmt.Sprintf("set active tab index of window 1 to %d", index))
+	err := execOsaScript(script, &stdout, &stderr, d.verbose)
+	if err != nil {
+		return fmt.Errorf("failed to activate tab: %w", err)
+	}
+
+	return nil
+}
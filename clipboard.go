@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"os/exec"
 )
 
 // Credit: modified from https://stackoverflow.com/questions/73812535/how-to-get-copied-text-from-clipboard-on-golang-mac
 
 // clipboard implements the io.ReadWriter interface
-type clipboard struct{}
+type clipboard struct {
+	content *bytes.Reader // Clipboard contents read on the first call to Read
+}
 
 func (c *clipboard) Write(content []byte) (int, error) {
 	cmd := exec.Command("pbcopy")
@@ -27,11 +30,37 @@ func (c *clipboard) Write(content []byte) (int, error) {
 	return len(content), cmd.Wait()
 }
 
+// Read reads the clipboard contents as they were at the first call, returning io.EOF once all contents
+// have been read
 func (c *clipboard) Read(buf []byte) (int, error) {
-	cmd := exec.Command("pbpaste")
-	out, err := cmd.Output()
-	if err != nil {
-		return 0, err
+	if c.content == nil {
+		cmd := exec.Command("pbpaste")
+		out, err := cmd.Output()
+		if err != nil {
+			return 0, err
+		}
+		c.content = bytes.NewReader(out)
+	}
+	return c.content.Read(buf)
+}
+
+// newClipboardWriter returns a writer that buffers all writes and copies them to the clipboard on close,
+// since each write to the clipboard replaces its contents. Nothing is copied if the writer is removed.
+func newClipboardWriter() *writeCloseRemover {
+	buf := &bytes.Buffer{}
+	removed := false
+	return &writeCloseRemover{
+		Writer: buf,
+		Closer: func() error {
+			if removed {
+				return nil
+			}
+			_, err := (&clipboard{}).Write(buf.Bytes())
+			return err
+		},
+		Remover: func() error {
+			removed = true
+			return nil
+		},
 	}
-	return copy(buf, out), nil
 }
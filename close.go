@@ -1,13 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"strings"
+	"time"
 )
 
 func runCloseCmd(cmd *flag.FlagSet, args []string) error {
@@ -26,23 +25,28 @@ func runCloseCmd(cmd *flag.FlagSet, args []string) error {
 
 type closeOptions struct {
 	*commonOptions
-	matchVals    []string
-	nonMatchVals []string
+	matcher *tabMatcher
+	dryRun  bool
+	confirm bool
+	journal *closeJournal // Journal recording closed tabs, not recorded if nil
+	input   io.Reader     // Input for confirmation
+	output  io.Writer     // Output for listing tabs to close
 }
 
 func parseCloseFlags(fs *flag.FlagSet, args []string) (*closeOptions, error) {
 	attachCommonFlags(fs)
+	matchFlags := attachMatchFlags(fs, "close")
 
 	var (
-		match = fs.String(
-			"match",
-			"",
-			"space delimited list of strings for matching tab URLs to close",
+		dryRun = fs.Bool(
+			"dry-run",
+			false,
+			"list the index, URL and name of each tab that would be closed without closing any tabs",
 		)
-		nonMatch = fs.String(
-			"no-match",
-			"",
-			"space delimited list of strings for non-matching tab URLs to close",
+		confirm = fs.Bool(
+			"confirm",
+			false,
+			"list the tabs that would be closed and prompt for confirmation before closing them",
 		)
 	)
 
@@ -62,84 +66,98 @@ func parseCloseFlags(fs *flag.FlagSet, args []string) (*closeOptions, error) {
 		return nil, err
 	}
 
+	matcher, err := matchFlags.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := newCloseJournal()
+	if err != nil {
+		return nil, err
+	}
+
 	opts := &closeOptions{
 		commonOptions: commonOpts,
-		matchVals:     strings.Split(*match, " "),
-		nonMatchVals:  strings.Split(*nonMatch, " "),
+		matcher:       matcher,
+		dryRun:        *dryRun,
+		confirm:       *confirm,
+		journal:       journal,
+		input:         os.Stdin,
+		output:        os.Stdout,
 	}
 	return opts, nil
 }
 
 func closeTabs(opts *closeOptions) error {
-	buf := &bytes.Buffer{}
-	builder := multiWriteCloseRemoverBuilder{}
-	builder.add(&writeCloseRemover{
-		Writer:  buf,
-		Closer:  func() error { return nil },
-		Remover: func() error { return nil },
-	})
-
-	err := grabTabs(&grabOptions{
-		commonOptions: opts.commonOptions,
-		urlWriter:     builder.build(),
-		template:      templateURL,
-	})
+	tabs, err := opts.driver.ListTabs(activeWindow, opts.maxTabs)
 	if err != nil {
 		return fmt.Errorf("failed to get tabs for matching: %w", err)
 	}
 
-	urlBytes, err := io.ReadAll(buf)
-	if err != nil {
-		return fmt.Errorf("failed to read tab URLs from buffer: %w", err)
-	}
-
-	urls := strings.Split(
-		strings.TrimSuffix(string(urlBytes), "\n"), "\n",
-	)
-
-	// Buffers to capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-
-	// Script to close URL of tab i
-	tabScript := "tell application \"" + opts.browserApp.cmdName + "\" to close tab %d of window 1"
-
-	for i, url := range urls {
-		if match(url, opts.matchVals, opts.nonMatchVals) {
-			err := execOsaScript(fmt.Sprintf(tabScript, i+1), &stdout, &stderr, opts.verbose)
-			if err != nil {
-				if errors.Is(err, errEndOfTabs) {
-					break
-				}
-				return fmt.Errorf("failed to close tab: %w", err)
-			}
+	matched := []*tabInfo{}
+	for _, tab := range tabs {
+		if opts.matcher.matches(tab) {
+			matched = append(matched, tab)
 		}
 	}
 
-	return nil
+	return closeListedTabs(opts, matched)
 }
 
-func match(url string, matchVals []string, nonMatchVals []string) bool {
-	numEvals := 0 // Track number of evaluations to avoid returning true on all empty values
-
-	for _, val := range matchVals {
-		if len(val) == 0 {
-			continue
+// closeListedTabs closes tabs listed from the active window, first listing them without closing any tabs
+// for a dry run or prompting to close them if confirmation is required, and recording them to the journal
+func closeListedTabs(opts *closeOptions, matched []*tabInfo) error {
+	if opts.dryRun || opts.confirm {
+		if len(matched) == 0 {
+			fmt.Fprintln(opts.output, "No tabs match")
+			return nil
 		}
-		numEvals++
-		if !strings.Contains(url, val) {
-			return false
+		for _, tab := range matched {
+			fmt.Fprintf(opts.output, "%d\t%s\t%s\n", tab.TabIndex, tab.URL, tab.Name)
+		}
+		if opts.dryRun {
+			return nil
+		}
+		if !promptContinue(opts.input, opts.output, fmt.Sprintf("Close %d tabs?", len(matched))) {
+			return errUserAbort
 		}
 	}
 
-	for _, val := range nonMatchVals {
-		if len(val) == 0 {
-			continue
+	// Record tabs before closing them so that closed tabs can always be reopened
+	if opts.journal != nil && len(matched) != 0 {
+		browser := ""
+		if opts.browserApp != nil {
+			browser = opts.browserApp.name
 		}
-		numEvals++
-		if strings.Contains(url, val) {
-			return false
+		err := opts.journal.record(&closedBatch{
+			ClosedAt: time.Now(),
+			Browser:  browser,
+			Tabs:     matched,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record tabs to close: %w", err)
 		}
 	}
 
-	return numEvals > 0
+	err := opts.driver.CloseTabs(matched)
+	if err != nil && errors.Is(err, errUnsupported) && opts.journal != nil && len(matched) != 0 {
+		// No tabs were closed, so remove the recorded tabs to avoid reopening them with undo. Tabs are kept
+		// after other errors as some of them may have been closed.
+		if removeErr := opts.journal.removeLast(); removeErr != nil {
+			return fmt.Errorf("failed to remove recorded tabs after %w: %v", err, removeErr)
+		}
+	}
+	return err
+}
+
+// promptContinue prompts until the user answers Y or n, returning true if the answer is Y
+func promptContinue(r io.Reader, w io.Writer, prompt string) bool {
+	var userInput string
+	for userInput != "Y" && userInput != "n" {
+		fmt.Fprintf(w, "%s [Y/n]: ", prompt)
+		if _, err := fmt.Fscanln(r, &userInput); err == io.EOF {
+			return false // Do not continue without an answer
+		}
+	}
+	return userInput == "Y"
 }
@@ -1,6 +1,14 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestMatch(tt *testing.T) {
 	tests := map[string]struct {
@@ -127,10 +135,250 @@ func TestMatch(tt *testing.T) {
 
 	for name, test := range tests {
 		tt.Run(name, func(t *testing.T) {
-			result := match(test.url, test.matchVals, test.nonMatchVals)
+			result := newSubstringMatcher(test.matchVals, test.nonMatchVals).matches(&tabInfo{URL: test.url})
 			if result != test.expected {
 				t.Errorf("expected %t, result %t", test.expected, result)
 			}
 		})
 	}
 }
+
+func TestCloseTabs(tt *testing.T) {
+	tests := map[string]struct {
+		matchVals    []string
+		nonMatchVals []string
+		expected     []string
+	}{
+		"no matching tabs": {
+			matchVals: []string{"xyz"},
+			expected:  []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+		},
+		"single matching tab": {
+			matchVals: []string{"bar"},
+			expected:  []string{"https://foo.com", "https://baz.com"},
+		},
+		"single non-matching tab": {
+			nonMatchVals: []string{"ba"},
+			expected:     []string{"https://bar.com", "https://baz.com"},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			driver := newFakeDriver(fakeTabs("https://foo.com", "https://bar.com", "https://baz.com"))
+			opts := &closeOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: defaultMaxTabs,
+				},
+				matcher: newSubstringMatcher(test.matchVals, test.nonMatchVals),
+			}
+			if err := closeTabs(opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := driver.urls(0); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCloseTabsAdjacentMatches(tt *testing.T) {
+	urls := []string{"https://foo.com", "https://bar.com/1", "https://bar.com/2", "https://bar.com/3", "https://baz.com"}
+
+	tests := map[string]struct {
+		withIDs   bool
+		matchVals []string
+		expected  []string
+	}{
+		"adjacent tabs by index": {
+			matchVals: []string{"bar"},
+			expected:  []string{"https://foo.com", "https://baz.com"},
+		},
+		"adjacent tabs by id": {
+			withIDs:   true,
+			matchVals: []string{"bar"},
+			expected:  []string{"https://foo.com", "https://baz.com"},
+		},
+		"every tab by index": {
+			matchVals: []string{"https"},
+			expected:  []string{},
+		},
+		"every tab by id": {
+			withIDs:   true,
+			matchVals: []string{"https"},
+			expected:  []string{},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			tabs := fakeTabs(urls...)
+			if test.withIDs {
+				for i, tab := range tabs {
+					tab.ID = fmt.Sprintf("%d", 100+i)
+				}
+			}
+			driver := newFakeDriver(tabs)
+			opts := &closeOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: defaultMaxTabs,
+				},
+				matcher: newSubstringMatcher(test.matchVals, nil),
+			}
+			if err := closeTabs(opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := driver.urls(0); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+// unsupportedCloseDriver is a fakeDriver for browsers that cannot close tabs
+type unsupportedCloseDriver struct {
+	*fakeDriver
+}
+
+func (d *unsupportedCloseDriver) CloseTabs(tabs []*tabInfo) error {
+	return fmt.Errorf("closing tabs: %w", errUnsupported)
+}
+
+func TestCloseTabsUnsupportedNotRecorded(t *testing.T) {
+	journal := &closeJournal{path: filepath.Join(t.TempDir(), journalFileName)}
+	earlier := &closedBatch{Tabs: fakeTabs("https://earlier.com")}
+	if err := journal.record(earlier); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := &closeOptions{
+		commonOptions: &commonOptions{
+			driver:  &unsupportedCloseDriver{newFakeDriver(fakeTabs("https://foo.com", "https://bar.com"))},
+			maxTabs: defaultMaxTabs,
+		},
+		matcher: newSubstringMatcher([]string{"bar"}, nil),
+		journal: journal,
+	}
+	if err := closeTabs(opts); !errors.Is(err, errUnsupported) {
+		t.Fatalf("expected error %v, result %v", errUnsupported, err)
+	}
+
+	last, err := journal.last()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := last.Tabs[0].URL; result != "https://earlier.com" {
+		t.Errorf("expected last recorded tab %s, result %s", "https://earlier.com", result)
+	}
+}
+
+func TestCloseTabsQuery(t *testing.T) {
+	tabs := fakeTabs("https://foo.atlassian.net/browse/FOO-1", "https://foo.atlassian.net/boards/1", "https://foo.atlassian.net/browse/FOO-2", "https://bar.com")
+	tabs[1].Name = "Sprint Board"
+	driver := newFakeDriver(tabs)
+	matcher := newSubstringMatcher(nil, []string{"FOO-2"})
+	if err := matcher.addQuery(`host:*.atlassian.net -title:"sprint board"`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := &closeOptions{
+		commonOptions: &commonOptions{
+			driver:  driver,
+			maxTabs: defaultMaxTabs,
+		},
+		matcher: matcher,
+	}
+	if err := closeTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://foo.atlassian.net/boards/1", "https://foo.atlassian.net/browse/FOO-2", "https://bar.com"}
+	if result := driver.urls(0); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+}
+
+func TestCloseTabsDryRunAndConfirm(tt *testing.T) {
+	tests := map[string]struct {
+		matchVals      []string
+		dryRun         bool
+		confirm        bool
+		input          string
+		expected       []string
+		expectedOutput string
+		expectedErr    error
+	}{
+		"dry run": {
+			matchVals:      []string{"ba"},
+			dryRun:         true,
+			expected:       []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedOutput: "2\thttps://bar.com\ttab 2\n3\thttps://baz.com\ttab 3\n",
+		},
+		"dry run without matching tabs": {
+			matchVals:      []string{"xyz"},
+			dryRun:         true,
+			expected:       []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedOutput: "No tabs match\n",
+		},
+		"dry run takes precedence over confirm": {
+			matchVals:      []string{"foo"},
+			dryRun:         true,
+			confirm:        true,
+			input:          "Y\n",
+			expected:       []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedOutput: "1\thttps://foo.com\ttab 1\n",
+		},
+		"confirmed": {
+			matchVals:      []string{"foo"},
+			confirm:        true,
+			input:          "yes\nY\n",
+			expected:       []string{"https://bar.com", "https://baz.com"},
+			expectedOutput: "1\thttps://foo.com\ttab 1\nClose 1 tabs? [Y/n]: Close 1 tabs? [Y/n]: ",
+		},
+		"declined": {
+			matchVals:      []string{"foo"},
+			confirm:        true,
+			input:          "n\n",
+			expected:       []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedOutput: "1\thttps://foo.com\ttab 1\nClose 1 tabs? [Y/n]: ",
+			expectedErr:    errUserAbort,
+		},
+		"no answer": {
+			matchVals:      []string{"foo"},
+			confirm:        true,
+			input:          "",
+			expected:       []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedOutput: "1\thttps://foo.com\ttab 1\nClose 1 tabs? [Y/n]: ",
+			expectedErr:    errUserAbort,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			driver := newFakeDriver(fakeTabs("https://foo.com", "https://bar.com", "https://baz.com"))
+			output := &bytes.Buffer{}
+			opts := &closeOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: defaultMaxTabs,
+				},
+				matcher: newSubstringMatcher(test.matchVals, nil),
+				dryRun:  test.dryRun,
+				confirm: test.confirm,
+				input:   strings.NewReader(test.input),
+				output:  output,
+			}
+			err := closeTabs(opts)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, result %v", test.expectedErr, err)
+			}
+			if result := driver.urls(0); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+			if result := output.String(); result != test.expectedOutput {
+				t.Errorf("expected output %q, result %q", test.expectedOutput, result)
+			}
+		})
+	}
+}
@@ -8,21 +8,36 @@ const (
 	tabCmdName           = "tabs"
 	tabCmdNameBackCompat = "tab" // Backwards compatibility with old command name
 	closeCmdName         = "close"
+	undoCmdName          = "undo"
+	dedupeCmdName        = "dedupe"
+	saveCmdName          = "save"
+	restoreCmdName       = "restore"
+	sessionsCmdName      = "sessions"
 	versionCmdName       = "version"
 )
 
 // Subcommands
 var (
-	grabCmd    = flag.NewFlagSet(grabCmdName, flag.ExitOnError)
-	tabCmd     = flag.NewFlagSet(tabCmdName, flag.ExitOnError)
-	closeCmd   = flag.NewFlagSet(closeCmdName, flag.ExitOnError)
-	versionCmd = flag.NewFlagSet(versionCmdName, flag.ExitOnError)
+	grabCmd     = flag.NewFlagSet(grabCmdName, flag.ExitOnError)
+	tabCmd      = flag.NewFlagSet(tabCmdName, flag.ExitOnError)
+	closeCmd    = flag.NewFlagSet(closeCmdName, flag.ExitOnError)
+	undoCmd     = flag.NewFlagSet(undoCmdName, flag.ExitOnError)
+	dedupeCmd   = flag.NewFlagSet(dedupeCmdName, flag.ExitOnError)
+	saveCmd     = flag.NewFlagSet(saveCmdName, flag.ExitOnError)
+	restoreCmd  = flag.NewFlagSet(restoreCmdName, flag.ExitOnError)
+	sessionsCmd = flag.NewFlagSet(sessionsCmdName, flag.ExitOnError)
+	versionCmd  = flag.NewFlagSet(versionCmdName, flag.ExitOnError)
 )
 
 // Subcommand descriptions
 const (
-	grabCmdDescription    = "extracts the URL from each tab of the active browser window"
-	tabCmdDescription     = "opens the provided URLs as tabs in a new browser window"
-	closeCmdDescription   = "closes tabs based on URL matching"
-	versionCmdDescription = "displays application version information"
+	grabCmdDescription     = "extracts the URL from each tab of the active browser window"
+	tabCmdDescription      = "opens the provided URLs as tabs in a new browser window"
+	closeCmdDescription    = "closes tabs based on URL matching"
+	undoCmdDescription     = "reopens the tabs most recently closed by the close or dedupe command in a new browser window"
+	dedupeCmdDescription   = "closes tabs of the active browser window with the same URL as another tab"
+	saveCmdDescription     = "saves the tabs of the active browser window as a named session"
+	restoreCmdDescription  = "opens the tabs of a named session saved by the save command"
+	sessionsCmdDescription = "lists saved sessions, or deletes a saved session with `sessions rm <name>`"
+	versionCmdDescription  = "displays application version information"
 )
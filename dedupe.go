@@ -0,0 +1,190 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+func runDedupeCmd(cmd *flag.FlagSet, args []string) error {
+	opts, err := parseDedupeFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	err = dedupeTabs(opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// URL normalizations applied before comparing tabs
+const (
+	normalizeTrailingSlash = "slash"    // Ignore a trailing slash of the path
+	normalizeFragment      = "fragment" // Ignore the fragment
+	normalizeUTM           = "utm"      // Ignore utm_* query parameters
+	normalizeScheme        = "scheme"   // Treat http as https
+)
+
+var urlNormalizations = []string{normalizeTrailingSlash, normalizeFragment, normalizeUTM, normalizeScheme}
+
+// Tab to keep of each group of duplicates
+const (
+	keepFirst  = "first"
+	keepActive = "active" // The active tab if it is a duplicate, otherwise the first tab
+)
+
+var keepOptions = []string{keepFirst, keepActive}
+
+type dedupeOptions struct {
+	*closeOptions
+	normalize []string // Normalizations applied to URLs before comparing them
+	keep      string
+}
+
+func parseDedupeFlags(fs *flag.FlagSet, args []string) (*dedupeOptions, error) {
+	attachCommonFlags(fs)
+
+	var (
+		normalize = fs.String(
+			"normalize",
+			strings.Join(urlNormalizations, " "),
+			fmt.Sprintf("space delimited list of normalizations applied to URLs before comparing them, any of %v, or empty to compare URLs exactly", urlNormalizations),
+		)
+		keep = fs.String(
+			"keep",
+			keepFirst,
+			fmt.Sprintf("tab to keep of each group of duplicate tabs, one of %v, where %s keeps the active tab if it is a duplicate", keepOptions, keepActive),
+		)
+		dryRun = fs.Bool(
+			"dry-run",
+			false,
+			"list the index, URL and name of each duplicate tab that would be closed without closing any tabs",
+		)
+		confirm = fs.Bool(
+			"confirm",
+			false,
+			"list the duplicate tabs that would be closed and prompt for confirmation before closing them",
+		)
+	)
+
+	defaultUsage := fs.Usage
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "`%s` %s\n\n", dedupeCmdName, dedupeCmdDescription)
+		defaultUsage()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := parseCommonOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	normalizations := []string{}
+	for _, n := range strings.Fields(*normalize) {
+		if !slices.Contains(urlNormalizations, n) {
+			return nil, fmt.Errorf("normalize must be a list of %v", urlNormalizations)
+		}
+		normalizations = append(normalizations, n)
+	}
+	if !slices.Contains(keepOptions, *keep) {
+		return nil, fmt.Errorf("keep must be one of %v", keepOptions)
+	}
+
+	journal, err := newCloseJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &dedupeOptions{
+		closeOptions: &closeOptions{
+			commonOptions: commonOpts,
+			dryRun:        *dryRun,
+			confirm:       *confirm,
+			journal:       journal,
+			input:         os.Stdin,
+			output:        os.Stdout,
+		},
+		normalize: normalizations,
+		keep:      *keep,
+	}
+	return opts, nil
+}
+
+func dedupeTabs(opts *dedupeOptions) error {
+	tabs, err := opts.driver.ListTabs(activeWindow, opts.maxTabs)
+	if err != nil {
+		return fmt.Errorf("failed to get tabs for comparing: %w", err)
+	}
+
+	duplicates := findDuplicateTabs(tabs, opts.normalize, opts.keep)
+	if len(duplicates) == 0 && (opts.dryRun || opts.confirm) {
+		fmt.Fprintln(opts.output, "No duplicate tabs")
+		return nil
+	}
+
+	return closeListedTabs(opts.closeOptions, duplicates)
+}
+
+// findDuplicateTabs returns every tab with the same normalized URL as another tab except for the tab to
+// keep of each group of duplicates, in the order of the provided tabs
+func findDuplicateTabs(tabs []*tabInfo, normalize []string, keep string) []*tabInfo {
+	kept := map[string]*tabInfo{} // Tab to keep for each normalized URL
+	for _, tab := range tabs {
+		key := normalizeURL(tab.URL, normalize)
+		if _, found := kept[key]; !found || (keep == keepActive && tab.Active) {
+			kept[key] = tab
+		}
+	}
+
+	duplicates := []*tabInfo{}
+	for _, tab := range tabs {
+		if kept[normalizeURL(tab.URL, normalize)] != tab {
+			duplicates = append(duplicates, tab)
+		}
+	}
+	return duplicates
+}
+
+// normalizeURL returns a URL with the provided normalizations applied and its host in lowercase, or the
+// URL unchanged if it cannot be parsed
+func normalizeURL(rawURL string, normalize []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	if slices.Contains(normalize, normalizeScheme) && u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	if slices.Contains(normalize, normalizeTrailingSlash) {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+	if slices.Contains(normalize, normalizeFragment) {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	if slices.Contains(normalize, normalizeUTM) {
+		// Filter the raw query to keep the order and encoding of the remaining parameters
+		params := []string{}
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			if !strings.HasPrefix(strings.ToLower(param), "utm_") {
+				params = append(params, param)
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+		u.ForceQuery = false
+	}
+	return u.String()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(tt *testing.T) {
+	tests := map[string]struct {
+		url       string
+		normalize []string
+		expected  string
+	}{
+		"every normalization": {
+			url:       "http://Foo.com/a/?q=1&utm_source=x&UTM_Medium=y#section",
+			normalize: urlNormalizations,
+			expected:  "https://foo.com/a?q=1",
+		},
+		"no normalizations only lowercases host": {
+			url:       "http://Foo.com/a/?utm_source=x#section",
+			normalize: []string{},
+			expected:  "http://foo.com/a/?utm_source=x#section",
+		},
+		"trailing slash of root path": {
+			url:       "https://foo.com/",
+			normalize: []string{normalizeTrailingSlash},
+			expected:  "https://foo.com",
+		},
+		"fragment only": {
+			url:       "https://foo.com/a/#b",
+			normalize: []string{normalizeFragment},
+			expected:  "https://foo.com/a/",
+		},
+		"utm parameters keep order of other parameters": {
+			url:       "https://foo.com/?b=2&utm_campaign=z&a=1",
+			normalize: []string{normalizeUTM},
+			expected:  "https://foo.com/?b=2&a=1",
+		},
+		"only utm parameters": {
+			url:       "https://foo.com/?utm_source=x",
+			normalize: []string{normalizeUTM},
+			expected:  "https://foo.com/",
+		},
+		"scheme other than http is unchanged": {
+			url:       "ftp://foo.com/a",
+			normalize: []string{normalizeScheme},
+			expected:  "ftp://foo.com/a",
+		},
+		"URL without host is unchanged": {
+			url:       "about:blank",
+			normalize: urlNormalizations,
+			expected:  "about:blank",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			if result := normalizeURL(test.url, test.normalize); result != test.expected {
+				t.Errorf("expected %s, result %s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFindDuplicateTabs(tt *testing.T) {
+	urls := []string{"https://foo.com/", "https://bar.com", "http://foo.com#top", "https://bar.com", "https://foo.com/?utm_source=x"}
+
+	tests := map[string]struct {
+		normalize []string
+		keep      string
+		active    int
+		expected  []int // Indices of duplicate tabs
+	}{
+		"keep first": {
+			normalize: urlNormalizations,
+			keep:      keepFirst,
+			active:    3,
+			expected:  []int{3, 4, 5},
+		},
+		"keep active": {
+			normalize: urlNormalizations,
+			keep:      keepActive,
+			active:    3,
+			expected:  []int{1, 4, 5},
+		},
+		"keep active that is not a duplicate": {
+			normalize: urlNormalizations,
+			keep:      keepActive,
+			active:    2,
+			expected:  []int{3, 4, 5},
+		},
+		"exact URLs": {
+			normalize: []string{},
+			keep:      keepFirst,
+			active:    1,
+			expected:  []int{4},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			tabs := fakeTabs(urls...)
+			for i, tab := range tabs {
+				tab.TabIndex = i + 1
+				tab.Active = i+1 == test.active
+			}
+			result := []int{}
+			for _, tab := range findDuplicateTabs(tabs, test.normalize, test.keep) {
+				result = append(result, tab.TabIndex)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestDedupeTabs(tt *testing.T) {
+	urls := []string{"https://foo.com", "https://bar.com", "https://foo.com/", "https://bar.com", "https://baz.com"}
+
+	tests := map[string]struct {
+		urls            []string
+		dryRun          bool
+		expected        []string
+		expectedOutput  string
+		expectedJournal bool
+	}{
+		"close duplicates": {
+			urls:            urls,
+			expected:        []string{"https://foo.com", "https://bar.com", "https://baz.com"},
+			expectedJournal: true,
+		},
+		"dry run": {
+			urls:           urls,
+			dryRun:         true,
+			expected:       urls,
+			expectedOutput: "3\thttps://foo.com/\ttab 3\n4\thttps://bar.com\ttab 4\n",
+		},
+		"dry run without duplicates": {
+			urls:           []string{"https://foo.com", "https://bar.com"},
+			dryRun:         true,
+			expected:       []string{"https://foo.com", "https://bar.com"},
+			expectedOutput: "No duplicate tabs\n",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			driver := newFakeDriver(fakeTabs(test.urls...))
+			journal := &closeJournal{path: filepath.Join(t.TempDir(), journalFileName)}
+			output := &bytes.Buffer{}
+			opts := &dedupeOptions{
+				closeOptions: &closeOptions{
+					commonOptions: &commonOptions{
+						driver:  driver,
+						maxTabs: defaultMaxTabs,
+					},
+					dryRun:  test.dryRun,
+					journal: journal,
+					output:  output,
+				},
+				normalize: urlNormalizations,
+				keep:      keepFirst,
+			}
+			if err := dedupeTabs(opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := driver.urls(0); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+			if result := output.String(); result != test.expectedOutput {
+				t.Errorf("expected output %q, result %q", test.expectedOutput, result)
+			}
+			if _, err := journal.last(); (err == nil) != test.expectedJournal {
+				t.Errorf("expected journal entry %t, result error %v", test.expectedJournal, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// tabDocument is the data of a document template, which is executed once with every grabbed tab
+type tabDocument struct {
+	Tabs      []*tabInfo   // Every tab in the order grabbed
+	Windows   [][]*tabInfo // Tabs grouped by window
+	Browser   string       // Name of the browser
+	GrabbedAt time.Time    // Time the tabs were grabbed
+	Count     int          // Number of tabs
+}
+
+// documentTabEncoder collects every tab and writes them with a document template after the last tab
+type documentTabEncoder struct {
+	w        io.Writer
+	template *template.Template
+	doc      *tabDocument
+}
+
+func newDocumentTabEncoder(w io.Writer, tmpl string, browser string) (*documentTabEncoder, error) {
+	t, err := template.New("document").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &documentTabEncoder{
+		w:        w,
+		template: t,
+		doc: &tabDocument{
+			Tabs:      []*tabInfo{},
+			Windows:   [][]*tabInfo{},
+			Browser:   browser,
+			GrabbedAt: time.Now(),
+		},
+	}, nil
+}
+
+func (e *documentTabEncoder) Encode(tab *tabInfo) error {
+	e.doc.Tabs = append(e.doc.Tabs, tab)
+	e.doc.Count++
+
+	// Tabs are grabbed in window order so a tab from a different window starts a new window
+	if n := len(e.doc.Windows); n == 0 || e.doc.Windows[n-1][0].WindowIndex != tab.WindowIndex {
+		e.doc.Windows = append(e.doc.Windows, []*tabInfo{})
+	}
+	e.doc.Windows[len(e.doc.Windows)-1] = append(e.doc.Windows[len(e.doc.Windows)-1], tab)
+	return nil
+}
+
+func (e *documentTabEncoder) Finish() error {
+	return e.template.Execute(e.w, e.doc)
+}
+
+// tabGroup is a group of tabs returned by the groupBy template function
+type tabGroup struct {
+	Key  string
+	Tabs []*tabInfo
+}
+
+// Keys for grouping tabs with the groupBy template function
+var tabGroupKeys = map[string]func(*tabInfo) string{
+	"host":   func(tab *tabInfo) string { return templateHost(tab.URL) },
+	"domain": func(tab *tabInfo) string { return templateDomain(tab.URL) },
+	"window": func(tab *tabInfo) string { return fmt.Sprint(tab.WindowIndex) },
+	"name":   func(tab *tabInfo) string { return tab.Name },
+}
+
+// templateGroupBy groups tabs by a key, ordering groups by their first tab
+func templateGroupBy(key string, tabs []*tabInfo) ([]*tabGroup, error) {
+	keyF, ok := tabGroupKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown groupBy key %q", key)
+	}
+
+	groups := []*tabGroup{}
+	groupIdx := map[string]int{}
+	for _, tab := range tabs {
+		k := keyF(tab)
+		idx, ok := groupIdx[k]
+		if !ok {
+			idx = len(groups)
+			groupIdx[k] = idx
+			groups = append(groups, &tabGroup{Key: k, Tabs: []*tabInfo{}})
+		}
+		groups[idx].Tabs = append(groups[idx].Tabs, tab)
+	}
+	return groups, nil
+}
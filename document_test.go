@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestGrabTabsDocumentTemplate(tt *testing.T) {
+	tabs := []*tabInfo{
+		{URL: "https://www.foo.com/a", Name: "Foo A"},
+		{URL: "https://bar.com", Name: "Bar"},
+		{URL: "https://foo.com/b", Name: "Foo B"},
+	}
+
+	tests := map[string]struct {
+		window      int
+		template    string
+		expected    string
+		expectedErr bool
+	}{
+		"header, count and groups": {
+			window: activeWindow,
+			template: "# Tabs from {{.Browser}}{{if not .GrabbedAt.IsZero}} grabbed today{{end}}\n" +
+				"{{range groupBy \"domain\" .Tabs}}\n## {{.Key}}\n{{range .Tabs}}- [{{.Name}}]({{.URL}})\n{{end}}{{end}}" +
+				"\n{{.Count}} tabs\n",
+			expected: "# Tabs from chrome grabbed today\n" +
+				"\n## foo.com\n- [Foo A](https://www.foo.com/a)\n- [Foo B](https://foo.com/b)\n" +
+				"\n## bar.com\n- [Bar](https://bar.com)\n" +
+				"\n3 tabs\n",
+		},
+		"windows": {
+			window:   allWindows,
+			template: "{{range $i, $w := .Windows}}Window {{$i}}:{{range $w}} {{.URL | host}}{{end}}\n{{end}}",
+			expected: "Window 0: www.foo.com bar.com foo.com\nWindow 1: other.com\n",
+		},
+		"no tabs": {
+			window:   3,
+			template: "{{.Count}} tabs{{range .Tabs}} {{.URL}}{{end}}",
+			expected: "0 tabs",
+		},
+		"unknown groupBy key": {
+			window:      activeWindow,
+			template:    "{{range groupBy \"foo\" .Tabs}}{{.Key}}{{end}}",
+			expectedErr: true,
+		},
+		"invalid template": {
+			window:      activeWindow,
+			template:    "{{.Tabs",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			opts := &grabOptions{
+				commonOptions: &commonOptions{
+					browserApp: browserApplications[browserNameChrome],
+					driver:     newFakeDriver(tabs, fakeTabs("https://other.com")),
+					maxTabs:    noTabLimit,
+					prefix:     "- ",
+				},
+				urlWriter:        newTestWriter(buf),
+				format:           formatText,
+				template:         templateURL,
+				documentTemplate: test.template,
+				window:           test.window,
+			}
+			err := grabTabs(opts)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := buf.String(); result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateGroupBy(tt *testing.T) {
+	tabs := []*tabInfo{
+		{URL: "https://www.foo.com", Name: "Foo", WindowIndex: 1},
+		{URL: "https://bar.com", Name: "Bar", WindowIndex: 2},
+		{URL: "https://foo.com:8080", Name: "Foo", WindowIndex: 2},
+	}
+
+	tests := map[string]struct {
+		key      string
+		expected [][]int // Indices of the tabs of each group
+		keys     []string
+	}{
+		"host": {
+			key:      "host",
+			expected: [][]int{{0}, {1}, {2}},
+			keys:     []string{"www.foo.com", "bar.com", "foo.com"},
+		},
+		"domain": {
+			key:      "domain",
+			expected: [][]int{{0, 2}, {1}},
+			keys:     []string{"foo.com", "bar.com"},
+		},
+		"window": {
+			key:      "window",
+			expected: [][]int{{0}, {1, 2}},
+			keys:     []string{"1", "2"},
+		},
+		"name": {
+			key:      "name",
+			expected: [][]int{{0, 2}, {1}},
+			keys:     []string{"Foo", "Bar"},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			groups, err := templateGroupBy(test.key, tabs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			keys := []string{}
+			result := [][]int{}
+			for _, group := range groups {
+				keys = append(keys, group.Key)
+				indices := []int{}
+				for _, tab := range group.Tabs {
+					for i := range tabs {
+						if tab == tabs[i] {
+							indices = append(indices, i)
+						}
+					}
+				}
+				result = append(result, indices)
+			}
+			if !reflect.DeepEqual(keys, test.keys) {
+				t.Errorf("expected keys %v, result %v", test.keys, keys)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+// Error returned by drivers for operations that the browser does not support
+var errUnsupported = errors.New("operation not supported for browser")
+
+// Maximum number of tabs for listing every tab
+const noTabLimit = 0
+
+// Window indices for listing tabs
+const (
+	allWindows   = 0 // Every window
+	activeWindow = 1 // The front window
+)
+
+// browserDriver performs tab operations against the windows of a browser application
+type browserDriver interface {
+	// ListTabs returns information for each tab of the window at the provided 1-based index, where
+	// the active window is window 1, or of every window if the index is allWindows, up to maxTabs tabs
+	// or without limit if maxTabs is noTabLimit
+	ListTabs(window int, maxTabs int) ([]*tabInfo, error)
+	// OpenTabs opens each URL as a tab of a new window, passing browserArgs to the browser
+	OpenTabs(urls []string, browserArgs string) error
+	// CloseTabs closes the provided tabs of the active window, as returned by ListTabs
+	CloseTabs(tabs []*tabInfo) error
+	// ActivateTab sets the tab at the provided 1-based index of the active window as the active tab
+	ActivateTab(index int) error
+}
+
+// tabStreamer is implemented by drivers that provide each tab as soon as it is read from the browser
+type tabStreamer interface {
+	// StreamTabs calls tabF with each tab that ListTabs would return, in the same order
+	StreamTabs(window int, maxTabs int, tabF func(*tabInfo) error) error
+}
+
+// streamTabs calls tabF with each tab listed by a driver, as each tab is read if the driver supports it
+func streamTabs(driver browserDriver, window int, maxTabs int, tabF func(*tabInfo) error) error {
+	if streamer, ok := driver.(tabStreamer); ok {
+		return streamer.StreamTabs(window, maxTabs, tabF)
+	}
+
+	tabs, err := driver.ListTabs(window, maxTabs)
+	if err != nil {
+		return err
+	}
+	for _, tab := range tabs {
+		if err := tabF(tab); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tabsFromEnd returns tabs ordered from the last tab to the first tab of their window so that closing a
+// tab by index does not change the index of the tabs remaining to be closed
+func tabsFromEnd(tabs []*tabInfo) []*tabInfo {
+	sorted := append([]*tabInfo{}, tabs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TabIndex > sorted[j].TabIndex
+	})
+	return sorted
+}
+
+// driverConfig contains the settings used to construct a browserDriver
+type driverConfig struct {
+	verbose    bool
+	cdpAddress string
+	profileDir string
+}
+
+// selectWindowTabs returns the tabs of the window at the provided 1-based index, or of every window
+// if the index is allWindows, up to maxTabs tabs unless maxTabs is noTabLimit. The windows are ordered
+// from front to back and the window and tab index of each returned tab is set from its position.
+func selectWindowTabs(windows [][]*tabInfo, window int, maxTabs int) []*tabInfo {
+	tabs := []*tabInfo{}
+	for i, windowTabs := range windows {
+		if window != allWindows && window != i+1 {
+			continue
+		}
+		for j, tab := range windowTabs {
+			if maxTabs != noTabLimit && len(tabs) == maxTabs {
+				return tabs
+			}
+			tab.WindowIndex = i + 1
+			tab.TabIndex = j + 1
+			tabs = append(tabs, tab)
+		}
+	}
+	return tabs
+}
@@ -0,0 +1,97 @@
+package main
+
+import "fmt"
+
+// fakeDriver is an in-memory browserDriver for testing commands without a browser
+type fakeDriver struct {
+	windows [][]*tabInfo // The first window is the active window
+	active  []int        // 1-based index of the active tab of each window
+	args    []string     // Browser arguments passed to each call of OpenTabs
+}
+
+func newFakeDriver(windows ...[]*tabInfo) *fakeDriver {
+	d := &fakeDriver{}
+	for _, tabs := range windows {
+		d.windows = append(d.windows, tabs)
+		d.active = append(d.active, 1)
+	}
+	return d
+}
+
+func (d *fakeDriver) ListTabs(window int, maxTabs int) ([]*tabInfo, error) {
+	// Copy tabs so that the fake's state is not modified by callers
+	windows := [][]*tabInfo{}
+	for i, tabs := range d.windows {
+		windowTabs := []*tabInfo{}
+		for j, tab := range tabs {
+			windowTab := *tab
+			windowTab.Active = d.active[i] == j+1
+			windowTabs = append(windowTabs, &windowTab)
+		}
+		windows = append(windows, windowTabs)
+	}
+	return selectWindowTabs(windows, window, maxTabs), nil
+}
+
+func (d *fakeDriver) OpenTabs(urls []string, browserArgs string) error {
+	tabs := []*tabInfo{}
+	for _, url := range urls {
+		tabs = append(tabs, &tabInfo{URL: url})
+	}
+	d.windows = append([][]*tabInfo{tabs}, d.windows...)
+	d.active = append([]int{len(tabs)}, d.active...)
+	d.args = append(d.args, browserArgs)
+	return nil
+}
+
+// CloseTabs closes tabs by identifier if they have one and otherwise by index from the end of the window,
+// mirroring the scripted browsers
+func (d *fakeDriver) CloseTabs(tabs []*tabInfo) error {
+	for _, tab := range tabsFromEnd(tabs) {
+		idx := tab.TabIndex
+		if tab.ID != "" {
+			idx = 0
+			for i, windowTab := range d.windows[0] {
+				if windowTab.ID == tab.ID {
+					idx = i + 1
+				}
+			}
+			if idx == 0 {
+				return fmt.Errorf("tab id %s of window 1 not found", tab.ID)
+			}
+		}
+		if len(d.windows) == 0 || idx < 1 || idx > len(d.windows[0]) {
+			break // Mirror the end-of-tabs behavior of scripted browsers
+		}
+		d.windows[0] = append(d.windows[0][:idx-1], d.windows[0][idx:]...)
+		if d.active[0] >= idx && d.active[0] > 1 {
+			d.active[0]--
+		}
+	}
+	return nil
+}
+
+func (d *fakeDriver) ActivateTab(index int) error {
+	if len(d.windows) == 0 || index < 1 || index > len(d.windows[0]) {
+		return fmt.Errorf("tab %d of window 1 not found", index)
+	}
+	d.active[0] = index
+	return nil
+}
+
+// urls returns the URLs of the tabs of the window at the provided 0-based index
+func (d *fakeDriver) urls(window int) []string {
+	urls := []string{}
+	for _, tab := range d.windows[window] {
+		urls = append(urls, tab.URL)
+	}
+	return urls
+}
+
+func fakeTabs(urls ...string) []*tabInfo {
+	tabs := []*tabInfo{}
+	for i, url := range urls {
+		tabs = append(tabs, &tabInfo{URL: url, Name: fmt.Sprintf("tab %d", i+1)})
+	}
+	return tabs
+}
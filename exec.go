@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
@@ -34,3 +35,45 @@ func execOsaScript(script string, stdout *bytes.Buffer, stderr *bytes.Buffer, ve
 
 	return nil
 }
+
+// execJXAScript runs a JavaScript for Automation script and calls lineF with each non-empty line the
+// script writes to stdout as soon as the line is written
+func execJXAScript(script string, lineF func([]byte) error, verbose bool) error {
+	// There is no intention that this implementation be secure so ignore the linter warning
+	cmd := exec.Command("osascript", "-l", "JavaScript", "-e", script) // #nosec
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		log.Printf("executing: %s\n", cmd.String())
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Read all output before waiting for the command to exit
+	var lineErr error
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadBytes('\n')
+		if lineErr == nil && len(bytes.TrimSpace(line)) > 0 {
+			lineErr = lineF(line)
+			if lineErr != nil {
+				_ = cmd.Process.Kill()
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil && lineErr == nil {
+		return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+	}
+	return lineErr
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// firefoxDriver implements tab operations for Firefox by reading the session files of a profile
+// directory, which does not require automation permissions
+type firefoxDriver struct {
+	app        *browserApplication
+	profileDir string
+	verbose    bool
+}
+
+func newFirefoxDriver(app *browserApplication, cfg *driverConfig) browserDriver {
+	return &firefoxDriver{
+		app:        app,
+		profileDir: cfg.profileDir,
+		verbose:    cfg.verbose,
+	}
+}
+
+func (d *firefoxDriver) ListTabs(window int, maxTabs int) ([]*tabInfo, error) {
+	profileDir := d.profileDir
+	if profileDir == "" {
+		rootDir, err := firefoxRootDir()
+		if err != nil {
+			return nil, err
+		}
+		profileDir, err = defaultFirefoxProfile(rootDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find default profile: %w", err)
+		}
+	}
+
+	sessionFile, err := firefoxSessionFile(profileDir)
+	if err != nil {
+		return nil, err
+	}
+	if d.verbose {
+		log.Printf("reading: %s\n", sessionFile)
+	}
+
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	session, err := parseFirefoxSession(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session file %s: %w", sessionFile, err)
+	}
+
+	return selectWindowTabs(session.windowTabs(), window, maxTabs), nil
+}
+
+func (d *firefoxDriver) OpenTabs(urls []string, browserArgs string) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	newWindowArg := "-new-window" // Open the first URL in a new window
+	for _, url := range urls {
+		args := []string{newWindowArg, url}
+		if browserArgs != "" {
+			args = append(strings.Fields(browserArgs), args...)
+		}
+		cmd := d.command(args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if d.verbose {
+			log.Printf("executing: %s\n", cmd.String())
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+		}
+		newWindowArg = "-new-tab" // Open the remaining URLs as tabs within the window
+	}
+
+	return nil
+}
+
+func (d *firefoxDriver) CloseTabs(tabs []*tabInfo) error {
+	return fmt.Errorf("closing tabs: %w %s", errUnsupported, d.app.name)
+}
+
+func (d *firefoxDriver) ActivateTab(index int) error {
+	return fmt.Errorf("activating tabs: %w %s", errUnsupported, d.app.name)
+}
+
+func (d *firefoxDriver) command(args ...string) *exec.Cmd {
+	if runtime.GOOS == "darwin" {
+		return exec.Command("open", append([]string{"-na", d.app.cmdName, "--args"}, args...)...)
+	}
+	return exec.Command("firefox", args...)
+}
+
+type firefoxSession struct {
+	Windows        []*firefoxWindow `json:"windows"`
+	SelectedWindow int              `json:"selectedWindow"` // 1-based
+}
+
+type firefoxWindow struct {
+	Tabs     []*firefoxTab `json:"tabs"`
+	Selected int           `json:"selected"` // 1-based
+}
+
+type firefoxTab struct {
+	Entries []*firefoxEntry `json:"entries"`
+	Index   int             `json:"index"` // 1-based index of the current history entry
+}
+
+type firefoxEntry struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+func parseFirefoxSession(data []byte) (*firefoxSession, error) {
+	raw, err := decodeMozLz4(data)
+	if err != nil {
+		return nil, err
+	}
+	session := &firefoxSession{}
+	if err := json.Unmarshal(raw, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// windowTabs returns the tabs of each window, with the selected window first
+func (s *firefoxSession) windowTabs() [][]*tabInfo {
+	windows := []*firefoxWindow{}
+	if s.SelectedWindow >= 1 && s.SelectedWindow <= len(s.Windows) {
+		windows = append(windows, s.Windows[s.SelectedWindow-1])
+	}
+	for i, window := range s.Windows {
+		if i+1 != s.SelectedWindow {
+			windows = append(windows, window)
+		}
+	}
+
+	windowTabs := [][]*tabInfo{}
+	for _, window := range windows {
+		tabs := []*tabInfo{}
+		for i, tab := range window.Tabs {
+			if len(tab.Entries) == 0 {
+				continue
+			}
+			entry := tab.Entries[len(tab.Entries)-1]
+			if tab.Index >= 1 && tab.Index <= len(tab.Entries) {
+				entry = tab.Entries[tab.Index-1]
+			}
+			tabs = append(tabs, &tabInfo{
+				URL:    entry.URL,
+				Name:   entry.Title,
+				Active: i+1 == window.Selected,
+			})
+		}
+		windowTabs = append(windowTabs, tabs)
+	}
+	return windowTabs
+}
+
+// firefoxSessionFile returns the most recently written session file of a profile directory
+func firefoxSessionFile(profileDir string) (string, error) {
+	candidates := []string{
+		filepath.Join(profileDir, "sessionstore-backups", "recovery.jsonlz4"), // Written while Firefox is running
+		filepath.Join(profileDir, "sessionstore.jsonlz4"),                     // Written when Firefox exits
+	}
+
+	sessionFile := mostRecentFile(candidates)
+	if sessionFile == "" {
+		return "", fmt.Errorf("no session file found in profile directory %s", profileDir)
+	}
+	return sessionFile, nil
+}
+
+func firefoxRootDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support", "Firefox"), nil
+	}
+	return filepath.Join(home, ".mozilla", "firefox"), nil
+}
+
+// defaultFirefoxProfile returns the default profile directory listed in the profiles.ini file of a
+// Firefox root directory
+func defaultFirefoxProfile(rootDir string) (string, error) {
+	f, err := os.Open(filepath.Join(rootDir, "profiles.ini"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	type profile struct {
+		path       string
+		isRelative bool
+		isDefault  bool
+	}
+	installDefault := ""
+	profiles := []*profile{}
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			if strings.HasPrefix(section, "Profile") {
+				profiles = append(profiles, &profile{})
+			}
+			continue
+		}
+		key, val, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(section, "Install") && key == "Default":
+			// The install default is always relative to the root directory
+			if installDefault == "" {
+				installDefault = filepath.Join(rootDir, filepath.FromSlash(val))
+			}
+		case strings.HasPrefix(section, "Profile"):
+			p := profiles[len(profiles)-1]
+			switch key {
+			case "Path":
+				p.path = val
+			case "IsRelative":
+				p.isRelative = val == "1"
+			case "Default":
+				p.isDefault = val == "1"
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if installDefault != "" {
+		return installDefault, nil
+	}
+	var selected *profile
+	for _, p := range profiles {
+		if p.path == "" {
+			continue
+		}
+		if selected == nil || (p.isDefault && !selected.isDefault) {
+			selected = p
+		}
+	}
+	if selected == nil {
+		return "", errors.New("no profiles found in profiles.ini")
+	}
+	if selected.isRelative {
+		return filepath.Join(rootDir, filepath.FromSlash(selected.path)), nil
+	}
+	return selected.path, nil
+}
+
+// mostRecentFile returns the most recently modified of the candidate files that exist
+func mostRecentFile(candidates []string) string {
+	file := ""
+	var modTime int64
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if file == "" || info.ModTime().UnixNano() > modTime {
+			file = candidate
+			modTime = info.ModTime().UnixNano()
+		}
+	}
+	return file
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFirefoxDriverListTabs(tt *testing.T) {
+	tests := map[string]struct {
+		profileDir string
+		window     int
+		maxTabs    int
+		expected   []*tabInfo
+	}{
+		"recovery file of running browser": {
+			profileDir: filepath.Join("testdata", "firefox", "recovery-profile"),
+			window:     activeWindow,
+			maxTabs:    100,
+			expected: []*tabInfo{
+				{URL: "https://news.ycombinator.com/", Name: "Hacker News", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://github.com/dkaslovsky/tabgrab/issues", Name: "Issues · dkaslovsky/tabgrab", WindowIndex: 1, TabIndex: 2},
+				{URL: "about:newtab", Name: "New Tab", WindowIndex: 1, TabIndex: 3},
+			},
+		},
+		"all windows with selected window first": {
+			profileDir: filepath.Join("testdata", "firefox", "recovery-profile"),
+			window:     allWindows,
+			maxTabs:    100,
+			expected: []*tabInfo{
+				{URL: "https://news.ycombinator.com/", Name: "Hacker News", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://github.com/dkaslovsky/tabgrab/issues", Name: "Issues · dkaslovsky/tabgrab", WindowIndex: 1, TabIndex: 2},
+				{URL: "about:newtab", Name: "New Tab", WindowIndex: 1, TabIndex: 3},
+				{URL: "https://www.mozilla.org/en-US/firefox/", Name: "Firefox Browser", WindowIndex: 2, TabIndex: 1},
+				{URL: "https://example.com/search?q=a,b&lang=en", Name: "Search, with commas", WindowIndex: 2, TabIndex: 2, Active: true},
+			},
+		},
+		"second window": {
+			profileDir: filepath.Join("testdata", "firefox", "recovery-profile"),
+			window:     2,
+			maxTabs:    100,
+			expected: []*tabInfo{
+				{URL: "https://www.mozilla.org/en-US/firefox/", Name: "Firefox Browser", WindowIndex: 2, TabIndex: 1},
+				{URL: "https://example.com/search?q=a,b&lang=en", Name: "Search, with commas", WindowIndex: 2, TabIndex: 2, Active: true},
+			},
+		},
+		"session file of closed browser": {
+			profileDir: filepath.Join("testdata", "firefox", "closed-profile"),
+			window:     activeWindow,
+			maxTabs:    100,
+			expected: []*tabInfo{
+				{URL: "https://www.espn.com/", Name: "ESPN - Serving Sports Fans. Anytime. Anywhere.", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://www.espn.com/nba/", Name: "NBA on ESPN", WindowIndex: 1, TabIndex: 2},
+			},
+		},
+		"max tabs less than number of tabs": {
+			profileDir: filepath.Join("testdata", "firefox", "closed-profile"),
+			window:     activeWindow,
+			maxTabs:    1,
+			expected: []*tabInfo{
+				{URL: "https://www.espn.com/", Name: "ESPN - Serving Sports Fans. Anytime. Anywhere.", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			driver := newFirefoxDriver(browserApplications[browserNameFirefox], &driverConfig{profileDir: test.profileDir})
+			result, err := driver.ListTabs(test.window, test.maxTabs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestFirefoxDriverListTabsMissingSession(t *testing.T) {
+	driver := newFirefoxDriver(browserApplications[browserNameFirefox], &driverConfig{profileDir: t.TempDir()})
+	if _, err := driver.ListTabs(activeWindow, defaultMaxTabs); err == nil {
+		t.Error("expected error for profile without session file")
+	}
+}
+
+func TestDecodeMozLz4(tt *testing.T) {
+	tests := map[string]struct {
+		data        []byte
+		expected    string
+		expectedErr bool
+	}{
+		"literals only": {
+			data:     append([]byte("mozLz40\x00\x03\x00\x00\x00"), 0x30, 'a', 'b', 'c'),
+			expected: "abc",
+		},
+		"overlapping match": {
+			// Literal "ab" followed by a match of length 6 at offset 2
+			data:     append([]byte("mozLz40\x00\x08\x00\x00\x00"), 0x22, 'a', 'b', 0x02, 0x00, 0x00),
+			expected: "abababab",
+		},
+		"extended literal length": {
+			data:     append(append([]byte("mozLz40\x00\x10\x00\x00\x00"), 0xf0, 0x01), []byte("0123456789abcdef")...),
+			expected: "0123456789abcdef",
+		},
+		"invalid magic number": {
+			data:        []byte("mozLz41\x00\x03\x00\x00\x00\x30abc"),
+			expectedErr: true,
+		},
+		"truncated literals": {
+			data:        append([]byte("mozLz40\x00\x03\x00\x00\x00"), 0x30, 'a'),
+			expectedErr: true,
+		},
+		"offset beyond output": {
+			data:        append([]byte("mozLz40\x00\x08\x00\x00\x00"), 0x12, 'a', 0x05, 0x00, 0x00),
+			expectedErr: true,
+		},
+		"literals exceed declared size": {
+			data:        append([]byte("mozLz40\x00\x02\x00\x00\x00"), 0x30, 'a', 'b', 'c'),
+			expectedErr: true,
+		},
+		"match exceeds declared size": {
+			data:        append([]byte("mozLz40\x00\x07\x00\x00\x00"), 0x22, 'a', 'b', 0x02, 0x00, 0x00),
+			expectedErr: true,
+		},
+		"declared size larger than preallocation": {
+			data:        append([]byte("mozLz40\x00\xff\xff\xff\xff"), 0x30, 'a', 'b', 'c'),
+			expectedErr: true,
+		},
+		"size mismatch": {
+			data:        append([]byte("mozLz40\x00\x04\x00\x00\x00"), 0x30, 'a', 'b', 'c'),
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, err := decodeMozLz4(test.data)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, string(result))
+			}
+		})
+	}
+}
+
+func TestDefaultFirefoxProfile(tt *testing.T) {
+	tests := map[string]struct {
+		ini         string
+		expected    string
+		expectedErr bool
+	}{
+		"install default": {
+			ini: "[Profile1]\nName=default\nIsRelative=1\nPath=Profiles/abc.default\nDefault=1\n\n" +
+				"[Profile0]\nName=default-release\nIsRelative=1\nPath=Profiles/xyz.default-release\n\n" +
+				"[Install4F96D1932A9F858E]\nDefault=Profiles/xyz.default-release\nLocked=1\n",
+			expected: "Profiles/xyz.default-release",
+		},
+		"profile marked as default": {
+			ini:      "[Profile0]\nName=a\nIsRelative=1\nPath=Profiles/a\n\n[Profile1]\nName=b\nIsRelative=1\nPath=Profiles/b\nDefault=1\n",
+			expected: "Profiles/b",
+		},
+		"first profile without default": {
+			ini:      "[General]\nStartWithLastProfile=1\n\n[Profile0]\nName=a\nIsRelative=1\nPath=Profiles/a\n",
+			expected: "Profiles/a",
+		},
+		"absolute profile path": {
+			ini:      "[Profile0]\nName=a\nIsRelative=0\nPath=/opt/profiles/a\nDefault=1\n",
+			expected: "/opt/profiles/a",
+		},
+		"no profiles": {
+			ini:         "[General]\nStartWithLastProfile=1\n",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(rootDir, "profiles.ini"), []byte(test.ini), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := defaultFirefoxProfile(rootDir)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := test.expected
+			if !filepath.IsAbs(expected) {
+				expected = filepath.Join(rootDir, filepath.FromSlash(expected))
+			}
+			if result != expected {
+				t.Errorf("expected %s, result %s", expected, result)
+			}
+		})
+	}
+}
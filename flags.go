@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -11,9 +12,11 @@ import (
 // Defaults
 const (
 	defaultBrowser = browserNameChrome
-	defaultMaxTabs = 100
+	defaultMaxTabs = noTabLimit
 	defaultPrefix  = ""
 
+	defaultCDPAddress = "localhost:9222"
+
 	defaultTemplate = templateURL
 )
 
@@ -22,16 +25,19 @@ const (
 	envVarPrefix      = "PREFIX"
 	envVarBrowser     = "BROWSER"
 	envVarBrowserArgs = "BROWSER_ARGS"
+	envVarCDPAddress  = "CDP_ADDRESS"
 
 	envVarTemplate = "TEMPLATE"
 )
 
 type commonFlags struct {
-	browser   string
-	maxTabs   int
-	prefix    string
-	clipboard bool
-	verbose   bool
+	browser    string
+	maxTabs    int
+	prefix     string
+	clipboard  bool
+	verbose    bool
+	cdpAddress string
+	profileDir string
 }
 
 // Global instance of the common flag values struct
@@ -39,14 +45,22 @@ var cFlags commonFlags
 
 func attachCommonFlags(fs *flag.FlagSet) {
 	fs.StringVar(&cFlags.browser, "browser", setStringFlagDefault(defaultBrowser, envVarBrowser), "browser name")
-	fs.IntVar(&cFlags.maxTabs, "max", defaultMaxTabs, "maximum number of tabs")
+	fs.IntVar(&cFlags.maxTabs, "max", defaultMaxTabs, "optional maximum number of tabs (default no limit)")
 	fs.StringVar(&cFlags.prefix, "prefix", setStringFlagDefault(defaultPrefix, envVarPrefix), "optional prefix for each URL")
 	fs.BoolVar(&cFlags.clipboard, "clipboard", false, "use clipboard for input/output")
 	fs.BoolVar(&cFlags.verbose, "verbose", false, "enable verbose output")
+	fs.StringVar(&cFlags.cdpAddress, "cdp-address", setStringFlagDefault(defaultCDPAddress, envVarCDPAddress), fmt.Sprintf("host:port of the remote debugging endpoint used by the %s browser", browserNameChromeCDP))
+}
+
+// attachProfileDirFlag attaches the flag for reading tabs from session files, which is only attached to
+// commands that do not open or close tabs as session files cannot be modified
+func attachProfileDirFlag(fs *flag.FlagSet) {
+	fs.StringVar(&cFlags.profileDir, "profile-dir", "", fmt.Sprintf("browser profile directory for reading tabs from session files instead of the running browser (%s uses the default profile if empty)", browserNameFirefox))
 }
 
 type commonOptions struct {
 	browserApp *browserApplication
+	driver     browserDriver
 	maxTabs    int
 	prefix     string
 	clipboard  bool
@@ -69,8 +83,8 @@ func parseCommonOptions() (*commonOptions, error) {
 	opts.browserApp = browserApp
 
 	// Set max tabs
-	if cFlags.maxTabs <= 0 || cFlags.maxTabs > defaultMaxTabs {
-		return nil, fmt.Errorf("maximum tabs must be in the range [1, %d]", defaultMaxTabs)
+	if cFlags.maxTabs < 0 {
+		return nil, errors.New("maximum tabs must not be negative")
 	}
 	opts.maxTabs = cFlags.maxTabs
 
@@ -78,6 +92,12 @@ func parseCommonOptions() (*commonOptions, error) {
 	opts.clipboard = cFlags.clipboard
 	opts.verbose = cFlags.verbose
 
+	opts.driver = browserApp.newDriver(browserApp, &driverConfig{
+		verbose:    opts.verbose,
+		cdpAddress: cFlags.cdpAddress,
+		profileDir: cFlags.profileDir,
+	})
+
 	return opts, nil
 }
 
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats for writing and reading grabbed tabs
+const (
+	formatText  = "text"  // Each tab written with a template
+	formatJSON  = "json"  // A JSON array of tabs
+	formatJSONL = "jsonl" // A JSON object per tab on each line
+
+	formatHTMLBookmarks = "html-bookmarks" // A Netscape bookmark file
+	formatOneTab        = "onetab"         // The import and export format of the OneTab extension
+)
+
+var outputFormats = []string{
+	formatText, formatJSON, formatJSONL, formatHTMLBookmarks, formatOneTab,
+	formatMarkdown, formatOrg, formatHTML, formatCSV, formatTSV,
+}
+
+// tabEncoder writes each grabbed tab to an output in a format
+type tabEncoder interface {
+	// Encode writes a tab
+	Encode(tab *tabInfo) error
+	// Finish writes any output following the last tab
+	Finish() error
+}
+
+// newTabEncoder returns an encoder for the format, using tmpl for the text format and grouping tabs by
+// window for formats that support it if allWindows is true
+func newTabEncoder(w io.Writer, format string, tmpl string, allWindows bool) (tabEncoder, error) {
+	switch format {
+	case formatJSON:
+		return &jsonTabEncoder{w: w, array: true}, nil
+	case formatJSONL:
+		return &jsonTabEncoder{w: w}, nil
+	case formatHTMLBookmarks:
+		return newBookmarksTabEncoder(w, allWindows), nil
+	case formatOneTab:
+		return &templateTabEncoder{w: w, writeF: oneTabWriteF(w)}, nil
+	case formatMarkdown:
+		return &templateTabEncoder{w: w, writeF: markdownWriteF(w)}, nil
+	case formatOrg:
+		return &templateTabEncoder{w: w, writeF: orgWriteF(w)}, nil
+	case formatHTML:
+		return &htmlTabEncoder{w: w}, nil
+	case formatCSV:
+		return newCSVTabEncoder(w), nil
+	case formatTSV:
+		return &tsvTabEncoder{w: w}, nil
+	default:
+		writeF, err := buildTemplateWriteF(w, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		return &templateTabEncoder{w: w, writeF: writeF}, nil
+	}
+}
+
+// templateTabEncoder writes each tab with a template, separating windows with a blank line
+type templateTabEncoder struct {
+	w          io.Writer
+	writeF     templatedTabInfoWriter
+	prevWindow int
+}
+
+func (e *templateTabEncoder) Encode(tab *tabInfo) error {
+	if e.prevWindow != 0 && tab.WindowIndex != e.prevWindow {
+		if _, err := io.WriteString(e.w, "\n"); err != nil {
+			return err
+		}
+	}
+	e.prevWindow = tab.WindowIndex
+	return e.writeF(tab)
+}
+
+func (e *templateTabEncoder) Finish() error {
+	return nil
+}
+
+// Separator between the URL and title of a tab in the OneTab format
+const oneTabSeparator = " | "
+
+// oneTabWriteF writes a tab as a line of the OneTab format, replacing line breaks in its title so that
+// the tab is written to a single line
+func oneTabWriteF(w io.Writer) templatedTabInfoWriter {
+	return func(tab *tabInfo) error {
+		_, err := fmt.Fprintf(w, "%s%s%s\n", tab.URL, oneTabSeparator, singleLine(tab.Name))
+		return err
+	}
+}
+
+// parseOneTabLine reads a tab from a line of the OneTab format, which is a URL optionally followed by
+// the separator and a title
+func parseOneTabLine(line string) (*tabInfo, bool) {
+	url, name, _ := strings.Cut(line, oneTabSeparator)
+	return &tabInfo{URL: strings.TrimSpace(url), Name: strings.TrimSpace(name)}, true
+}
+
+// jsonTabEncoder writes each tab as a JSON object, either as an element of an array or on its own line
+type jsonTabEncoder struct {
+	w     io.Writer
+	array bool
+	count int
+}
+
+func (e *jsonTabEncoder) Encode(tab *tabInfo) error {
+	// Do not escape HTML characters as URLs commonly contain '&'
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(tab); err != nil {
+		return err
+	}
+
+	if e.array {
+		sep := ",\n"
+		if e.count == 0 {
+			sep = "[\n"
+		}
+		if _, err := io.WriteString(e.w, sep); err != nil {
+			return err
+		}
+		// Omit the newline written by the encoder so that a separator can follow
+		buf.Truncate(buf.Len() - 1)
+	}
+	e.count++
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *jsonTabEncoder) Finish() error {
+	if !e.array {
+		return nil
+	}
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// readJSONTabs reads tabs written in the json format, or in the jsonl format if lines is true. A record
+// that cannot be read is reported with the line number on which it starts.
+func readJSONTabs(r io.ReadCloser, lines bool) ([]*tabInfo, error) {
+	defer r.Close()
+
+	if lines {
+		return readJSONLinesTabs(r)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tabs from reader: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
+	lineNum := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		if err == io.EOF {
+			return []*tabInfo{}, nil
+		}
+		return nil, fmt.Errorf("line %d: %w", lineNum(dec.InputOffset()), err)
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("line %d: expected an array of tabs", lineNum(0))
+	}
+
+	tabs := []*tabInfo{}
+	for dec.More() {
+		// Skip whitespace to report the line on which the record starts
+		offset := dec.InputOffset()
+		offset += int64(len(data[offset:]) - len(bytes.TrimLeft(data[offset:], " \t\r\n,")))
+
+		raw := json.RawMessage{}
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum(offset), err)
+		}
+		tab, err := parseTabInfo(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum(offset), err)
+		}
+		tabs = append(tabs, tab)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", lineNum(dec.InputOffset()), err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("line %d: unexpected data following tabs", lineNum(dec.InputOffset()))
+	}
+	return tabs, nil
+}
+
+// readJSONLinesTabs reads a tab from each non-blank line
+func readJSONLinesTabs(r io.Reader) ([]*tabInfo, error) {
+	tabs := []*tabInfo{}
+
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read tabs from reader: %w", err)
+		}
+		if lineNum == 1 {
+			line = bytes.TrimPrefix(line, []byte(utf8BOM))
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			tab, parseErr := parseTabInfo(line)
+			if parseErr != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, parseErr)
+			}
+			tabs = append(tabs, tab)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return tabs, nil
+}
+
+// groupWindowURLs returns the URLs of tabs grouped by window, with windows ordered by their first tab
+// and tabs without a window grouped together
+func groupWindowURLs(tabs []*tabInfo) [][]string {
+	windows := [][]string{}
+	windowIdx := map[int]int{} // Index of each window in windows
+	for _, tab := range tabs {
+		if tab.URL == "" {
+			continue
+		}
+		idx, ok := windowIdx[tab.WindowIndex]
+		if !ok {
+			idx = len(windows)
+			windowIdx[tab.WindowIndex] = idx
+			windows = append(windows, []string{})
+		}
+		windows[idx] = append(windows[idx], tab.URL)
+	}
+	return windows
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONTabs(tt *testing.T) {
+	tests := map[string]struct {
+		input       string
+		lines       bool
+		expected    []*tabInfo
+		expectedErr string
+	}{
+		"json array": {
+			input: "[\n" +
+				`{"url":"https://foo.com","name":"foo","window":1,"tab":1,"active":true},` + "\n" +
+				`{"url":"https://bar.com","name":"bar","window":2,"tab":1,"active":false}` + "\n]\n",
+			expected: []*tabInfo{
+				{URL: "https://foo.com", Name: "foo", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://bar.com", Name: "bar", WindowIndex: 2, TabIndex: 1},
+			},
+		},
+		"json array with unknown fields and byte order mark": {
+			input:    "\ufeff[{\"url\":\"https://foo.com\",\"extra\":[1,2]}]",
+			expected: []*tabInfo{{URL: "https://foo.com"}},
+		},
+		"empty json input": {
+			input:    "\n",
+			expected: []*tabInfo{},
+		},
+		"json object instead of array": {
+			input:       `{"url":"https://foo.com"}`,
+			expectedErr: "line 1: expected an array of tabs",
+		},
+		"json record missing URL": {
+			input:       "[\n  {\"url\":\"https://foo.com\"},\n  {\"name\":\"bar\"}\n]",
+			expectedErr: "line 3: tab is missing a URL",
+		},
+		"json record with invalid field type": {
+			input:       "[\n{\"url\":\"https://foo.com\"},\n\n{\"url\":\"https://bar.com\",\n\"window\":\"1\"}]",
+			expectedErr: "line 4: ",
+		},
+		"malformed json record": {
+			input:       "[\n{\"url\":\"https://foo.com\"}\n{\"url\":\"https://bar.com\"}\n]",
+			expectedErr: "line 3: ",
+		},
+		"data following json array": {
+			input:       "[{\"url\":\"https://foo.com\"}]\n[]",
+			expectedErr: "line 2: unexpected data following tabs",
+		},
+		"json lines": {
+			input: `{"url":"https://foo.com","name":"foo","window":1,"tab":1,"active":true}` + "\r\n\r\n" +
+				`{"url":"https://bar.com","name":"bar","window":2,"tab":1}`,
+			lines: true,
+			expected: []*tabInfo{
+				{URL: "https://foo.com", Name: "foo", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://bar.com", Name: "bar", WindowIndex: 2, TabIndex: 1},
+			},
+		},
+		"empty json lines input": {
+			input:    "",
+			lines:    true,
+			expected: []*tabInfo{},
+		},
+		"malformed json line": {
+			input:       "{\"url\":\"https://foo.com\"}\n\n{\"url\":\"https://bar.com\"\n",
+			lines:       true,
+			expectedErr: "line 3: ",
+		},
+		"json line with multiple records": {
+			input:       "{\"url\":\"https://foo.com\"} {\"url\":\"https://bar.com\"}\n",
+			lines:       true,
+			expectedErr: "line 1: unexpected data following tab",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			r := &urlReadCloser{
+				Reader: strings.NewReader(test.input),
+				Closer: func() error { return nil },
+			}
+			result, err := readJSONTabs(r, test.lines)
+			if test.expectedErr != "" {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if !strings.HasPrefix(err.Error(), test.expectedErr) {
+					t.Errorf("expected error starting with %q, result %q", test.expectedErr, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestGroupWindowURLs(t *testing.T) {
+	tabs := []*tabInfo{
+		{URL: "https://foo.com", WindowIndex: 2},
+		{URL: "https://bar.com", WindowIndex: 1},
+		{URL: "", WindowIndex: 3},
+		{URL: "https://baz.com", WindowIndex: 2},
+		{URL: "https://qux.com"},
+	}
+	expected := [][]string{{"https://foo.com", "https://baz.com"}, {"https://bar.com"}, {"https://qux.com"}}
+	if result := groupWindowURLs(tabs); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+}
+
+func TestOneTabWriteF(t *testing.T) {
+	buf := &strings.Builder{}
+	writeF := oneTabWriteF(buf)
+	tabs := []*tabInfo{
+		{URL: "https://foo.com", Name: "Foo | Bar"},
+		{URL: "https://bar.com", Name: "Line 1\r\nLine 2\nLine 3"},
+		{URL: "https://baz.com"},
+	}
+	for _, tab := range tabs {
+		if err := writeF(tab); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "https://foo.com | Foo | Bar\nhttps://bar.com | Line 1 Line 2 Line 3\nhttps://baz.com | \n"
+	if result := buf.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+}
+
+func TestParseOneTabLine(tt *testing.T) {
+	tests := map[string]struct {
+		line     string
+		expected *tabInfo
+	}{
+		"URL and title": {
+			line:     "https://foo.com | Foo",
+			expected: &tabInfo{URL: "https://foo.com", Name: "Foo"},
+		},
+		"title containing separator": {
+			line:     "https://foo.com | Foo | Bar",
+			expected: &tabInfo{URL: "https://foo.com", Name: "Foo | Bar"},
+		},
+		"empty title": {
+			line:     "https://foo.com | ",
+			expected: &tabInfo{URL: "https://foo.com"},
+		},
+		"URL without title": {
+			line:     "https://foo.com",
+			expected: &tabInfo{URL: "https://foo.com"},
+		},
+		"URL containing pipe": {
+			line:     "https://foo.com/?a=1|2 | Foo",
+			expected: &tabInfo{URL: "https://foo.com/?a=1|2", Name: "Foo"},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, ok := parseOneTabLine(test.line)
+			if !ok {
+				t.Fatal("expected line to be parsed")
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
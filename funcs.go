@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions available to templates, with arguments ordered so that the value
+// being formatted is the last argument and can be piped, for example {{.URL | query "q"}}
+var templateFuncs = template.FuncMap{
+	"host":       templateHost,
+	"domain":     templateDomain,
+	"path":       templatePath,
+	"query":      templateQuery,
+	"trunc":      templateTrunc,
+	"mdEscape":   templateMarkdownEscape,
+	"htmlEscape": html.EscapeString,
+	"jsonEscape": templateJSONEscape,
+	"csvEscape":  templateCSVEscape,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"now":        templateNow,
+	"replace":    templateReplace,
+	"default":    templateDefault,
+	"groupBy":    templateGroupBy,
+}
+
+// templateHost returns the host of a URL without its port
+func templateHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// templateDomain returns the host of a URL without its port or a leading "www."
+func templateDomain(rawURL string) string {
+	return strings.TrimPrefix(templateHost(rawURL), "www.")
+}
+
+// templatePath returns the path of a URL
+func templatePath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// templateQuery returns the first value of a query parameter of a URL
+func templateQuery(key string, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get(key)
+}
+
+// templateTrunc returns at most the first n characters of a string
+func templateTrunc(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// Characters with special meaning in Markdown text
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`, `!`, `\!`, `~`, `\~`,
+)
+
+// templateMarkdownEscape escapes characters with special meaning in Markdown text
+func templateMarkdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// templateJSONEscape escapes a string for use within the quotes of a JSON string
+func templateJSONEscape(s string) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	// Encoding a string cannot fail
+	_ = enc.Encode(s)
+	encoded := strings.TrimSuffix(sb.String(), "\n")
+	return encoded[1 : len(encoded)-1]
+}
+
+// templateCSVEscape quotes a string as a CSV field if it contains a character that requires quoting
+func templateCSVEscape(s string) string {
+	if s == "" || (!strings.ContainsAny(s, ",\"\r\n") && s[0] != ' ' && s[len(s)-1] != ' ') {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// templateNow returns the current time formatted with a Go time layout
+func templateNow(layout string) string {
+	return time.Now().Format(layout)
+}
+
+// templateReplace replaces every occurrence of old with new in a string
+func templateReplace(old string, new string, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// templateDefault returns def if a string is empty
+func templateDefault(def string, s string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
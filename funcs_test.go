@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTemplateFuncs(tt *testing.T) {
+	tab := &tabInfo{
+		URL:  "https://www.foo.com:8080/bar/baz?q=a+b&n=1#frag",
+		Name: `Foo "Bar", [Baz] & <Qux>`,
+	}
+
+	tests := map[string]struct {
+		template string
+		expected string
+	}{
+		"host": {
+			template: "{{.URL | host}}",
+			expected: "www.foo.com",
+		},
+		"domain": {
+			template: "{{.URL | domain}}",
+			expected: "foo.com",
+		},
+		"path": {
+			template: "{{.URL | path}}",
+			expected: "/bar/baz",
+		},
+		"query": {
+			template: `{{.URL | query "q"}}`,
+			expected: "a b",
+		},
+		"missing query parameter": {
+			template: `{{.URL | query "missing"}}`,
+			expected: "",
+		},
+		"trunc": {
+			template: "{{.Name | trunc 3}}",
+			expected: "Foo",
+		},
+		"trunc multibyte characters": {
+			template: `{{"café au lait" | trunc 4}}`,
+			expected: "café",
+		},
+		"trunc longer than string": {
+			template: "{{.Name | trunc 100}}",
+			expected: tab.Name,
+		},
+		"mdEscape": {
+			template: "[{{.Name | mdEscape}}]({{.URL}})",
+			expected: `[Foo "Bar", \[Baz\] & \<Qux\>](https://www.foo.com:8080/bar/baz?q=a+b&n=1#frag)`,
+		},
+		"htmlEscape": {
+			template: "{{.Name | htmlEscape}}",
+			expected: "Foo &#34;Bar&#34;, [Baz] &amp; &lt;Qux&gt;",
+		},
+		"jsonEscape": {
+			template: `{"name":"{{.Name | jsonEscape}}"}`,
+			expected: `{"name":"Foo \"Bar\", [Baz] & <Qux>"}`,
+		},
+		"csvEscape": {
+			template: "{{.Name | csvEscape}},{{.URL | csvEscape}}",
+			expected: `"Foo ""Bar"", [Baz] & <Qux>",https://www.foo.com:8080/bar/baz?q=a+b&n=1#frag`,
+		},
+		"lower and upper": {
+			template: "{{.Name | lower}} {{.URL | host | upper}}",
+			expected: `foo "bar", [baz] & <qux> WWW.FOO.COM`,
+		},
+		"replace": {
+			template: `{{.Name | replace "&" "and"}}`,
+			expected: `Foo "Bar", [Baz] and <Qux>`,
+		},
+		"default for empty value": {
+			template: `{{"" | default "untitled"}}`,
+			expected: "untitled",
+		},
+		"default for non-empty value": {
+			template: `{{.Name | trunc 3 | default "untitled"}}`,
+			expected: "Foo",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			writeF, err := buildTemplateWriteF(buf, test.template)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := writeF(tab); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := buf.String(); result != test.expected+"\n" {
+				t.Errorf("expected %q, result %q", test.expected+"\n", result)
+			}
+		})
+	}
+}
+
+func TestTemplateNow(t *testing.T) {
+	layout := "2006-01-02"
+	before := time.Now().Format(layout)
+	result := templateNow(layout)
+	after := time.Now().Format(layout)
+	if result != before && result != after {
+		t.Errorf("expected %q, result %q", before, result)
+	}
+}
+
+func TestTemplateCSVEscape(tt *testing.T) {
+	tests := map[string]struct {
+		s        string
+		expected string
+	}{
+		"empty":              {s: "", expected: ""},
+		"plain":              {s: "foo bar", expected: "foo bar"},
+		"comma":              {s: "foo,bar", expected: `"foo,bar"`},
+		"quote":              {s: `foo "bar"`, expected: `"foo ""bar"""`},
+		"newline":            {s: "foo\nbar", expected: "\"foo\nbar\""},
+		"surrounding spaces": {s: " foo ", expected: `" foo "`},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			if result := templateCSVEscape(test.s); result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
@@ -3,13 +3,18 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"slices"
 )
 
+// errTabLimit stops streaming tabs once the maximum number of matching tabs is written
+var errTabLimit = errors.New("tab limit reached")
+
 func runGrabCmd(cmd *flag.FlagSet, args []string) error {
 	opts, err := parseGrabFlags(cmd, args)
 	if err != nil {
@@ -26,12 +31,18 @@ func runGrabCmd(cmd *flag.FlagSet, args []string) error {
 
 type grabOptions struct {
 	*commonOptions
-	urlWriter *writeCloseRemover
-	template  string
+	urlWriter        *writeCloseRemover
+	matcher          *tabMatcher // Grab only matching tabs, or every tab if nil
+	format           string
+	template         string
+	documentTemplate string // Template for every tab, overriding template if not empty
+	window           int
 }
 
 func parseGrabFlags(fs *flag.FlagSet, args []string) (*grabOptions, error) {
 	attachCommonFlags(fs)
+	attachProfileDirFlag(fs)
+	matchFlags := attachMatchFlags(fs, "grab")
 
 	var (
 		urlFile = fs.String(
@@ -44,11 +55,36 @@ func parseGrabFlags(fs *flag.FlagSet, args []string) (*grabOptions, error) {
 			false,
 			"disable console output",
 		)
+		allWindowsFlag = fs.Bool(
+			"all-windows",
+			false,
+			"grab tabs from all browser windows, separating windows with a blank line",
+		)
+		window = fs.Int(
+			"window",
+			activeWindow,
+			"index of the browser window to grab tabs from, ordered from front to back, ignored if -all-windows flag is used",
+		)
+		sessionFile = fs.String(
+			"session-file",
+			"",
+			"path to a Chromium session file for reading tabs instead of the running browser",
+		)
 		template = fs.String(
 			"template",
 			setStringFlagDefault(defaultTemplate, envVarTemplate),
 			"output format specifying tab URL with {{.URL}} tab name with {{.Name}}",
 		)
+		templateFile = fs.String(
+			"template-file",
+			"",
+			"path to a template file for the whole output, executed once with every tab as .Tabs, tabs grouped by window as .Windows, .Browser, .GrabbedAt, .Count, and a groupBy function, overriding the template and prefix",
+		)
+		format = fs.String(
+			"format",
+			formatText,
+			fmt.Sprintf("output format, one of %v, where %s writes each tab with the template and other formats ignore the prefix and template", outputFormats, formatText),
+		)
 	)
 
 	defaultUsage := fs.Usage
@@ -67,13 +103,44 @@ func parseGrabFlags(fs *flag.FlagSet, args []string) (*grabOptions, error) {
 		return nil, err
 	}
 
+	matcher, err := matchFlags.parse()
+	if err != nil {
+		return nil, err
+	}
+	if matcher.empty() {
+		matcher = nil
+	}
+
+	if !slices.Contains(outputFormats, *format) {
+		return nil, fmt.Errorf("format must be one of %v", outputFormats)
+	}
+
+	documentTemplate := ""
+	if *templateFile != "" {
+		if *format != formatText {
+			return nil, fmt.Errorf("template-file flag requires the %s format", formatText)
+		}
+		tmpl, err := os.ReadFile(*templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		documentTemplate = string(tmpl)
+	}
+
+	if *window < 1 {
+		return nil, errors.New("window index must be positive")
+	}
+	if *allWindowsFlag {
+		*window = allWindows
+	}
+
+	if *sessionFile != "" {
+		commonOpts.driver = newSessionFileDriver(*sessionFile, commonOpts.driver, commonOpts.verbose)
+	}
+
 	builder := multiWriteCloseRemoverBuilder{}
 	if commonOpts.clipboard {
-		builder.add(&writeCloseRemover{
-			Writer:  &clipboard{},
-			Closer:  func() error { return nil },
-			Remover: func() error { return nil },
-		})
+		builder.add(newClipboardWriter())
 	}
 	if *urlFile != "" {
 		f, err := os.Create(*urlFile)
@@ -96,9 +163,13 @@ func parseGrabFlags(fs *flag.FlagSet, args []string) (*grabOptions, error) {
 	urlWriter := builder.build()
 
 	opts := &grabOptions{
-		commonOptions: commonOpts,
-		urlWriter:     urlWriter,
-		template:      *template,
+		commonOptions:    commonOpts,
+		urlWriter:        urlWriter,
+		matcher:          matcher,
+		format:           *format,
+		template:         *template,
+		documentTemplate: documentTemplate,
+		window:           *window,
 	}
 	return opts, nil
 }
@@ -109,39 +180,62 @@ func grabTabs(opts *grabOptions) error {
 	defer func() {
 		if writeCleanup {
 			_ = opts.urlWriter.Remove()
+			_ = opts.urlWriter.Close()
 		}
 	}()
 
-	// Buffers to capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-
-	// Script to capture URL of tab i
-	tabScript := "tell application \"" + opts.browserApp.cmdName + "\" to get {URL, NAME} of tab %d of window 1"
-
-	for i := 0; i < opts.maxTabs; i++ {
-		err := execOsaScript(fmt.Sprintf(tabScript, i+1), &stdout, &stderr, opts.verbose)
-		if err != nil {
-			if errors.Is(err, errEndOfTabs) {
-				break
-			}
-			return fmt.Errorf("failed to get tab: %w", err)
+	writer := bufio.NewWriter(opts.urlWriter)
+	var encoder tabEncoder
+	var err error
+	if opts.documentTemplate != "" {
+		browser := ""
+		if opts.browserApp != nil {
+			browser = opts.browserApp.name
 		}
+		encoder, err = newDocumentTabEncoder(writer, opts.documentTemplate, browser)
+	} else {
+		encoder, err = newTabEncoder(writer, opts.format, fmt.Sprintf("%s%s", opts.prefix, opts.template), opts.window == allWindows)
 	}
-
-	// Write output
-	defer opts.urlWriter.Close()
-	writer := bufio.NewWriter(opts.urlWriter)
-	writeF, err := buildTemplateWriteF(writer, fmt.Sprintf("%s%s", opts.prefix, opts.template))
 	if err != nil {
 		return fmt.Errorf("failed to construct writer template: %w", err)
 	}
-	for _, tab := range parseTabInfo(stdout) {
+
+	// Count only matching tabs toward the maximum, stopping the stream once it is reached
+	streamMax := opts.maxTabs
+	if opts.matcher != nil {
+		streamMax = noTabLimit
+	}
+	matched := 0
+
+	// Write each tab as soon as it is read
+	err = streamTabs(opts.driver, opts.window, streamMax, func(tab *tabInfo) error {
+		if opts.matcher != nil && !opts.matcher.matches(tab) {
+			return nil
+		}
+		if opts.matcher != nil && opts.maxTabs != noTabLimit && matched == opts.maxTabs {
+			return errTabLimit
+		}
+		matched++
 		if tab.URL != "" || tab.Name != "" {
-			err := writeF(tab)
+			err := encoder.Encode(tab)
 			if err != nil {
 				return fmt.Errorf("failed to write output to buffer: %w", err)
 			}
 		}
+
+		err := writer.Flush()
+		if err != nil {
+			return fmt.Errorf("failed to flush buffer: %w", err)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTabLimit) {
+		return err
+	}
+
+	err = encoder.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to write output to buffer: %w", err)
 	}
 	err = writer.Flush()
 	if err != nil {
@@ -149,30 +243,55 @@ func grabTabs(opts *grabOptions) error {
 	}
 
 	writeCleanup = false
+	err = opts.urlWriter.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close output: %w", err)
+	}
 	return nil
 }
 
 type tabInfo struct {
-	URL  string
-	Name string
+	ID          string `json:"id,omitempty"` // Identifier of the tab assigned by the browser, if supported
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	WindowIndex int    `json:"window"` // 1-based index of the tab's window, ordered from front to back
+	TabIndex    int    `json:"tab"`    // 1-based index of the tab within its window
+	Active      bool   `json:"active"` // Whether the tab is the active tab of its window
 }
 
-func parseTabInfo(raw bytes.Buffer) []*tabInfo {
-	tInfo := []*tabInfo{}
-	tabs := strings.Split(raw.String(), "\n")
-	for _, tab := range tabs {
-		if tab == "" {
-			continue
-		}
-		info := strings.SplitN(tab, ",", 2)
-		tabName := ""
-		if len(info) == 2 {
-			tabName = strings.TrimSpace(info[1])
-		}
-		tInfo = append(tInfo, &tabInfo{
-			URL:  info[0],
-			Name: tabName,
-		})
+// parseTabInfo decodes a line of JSON written by the list tabs script. Encoding each tab as a JSON
+// object allows URLs and names to contain any character, including commas and newlines.
+func parseTabInfo(line []byte) (*tabInfo, error) {
+	record := struct {
+		ID     string  `json:"id"`
+		URL    *string `json:"url"`
+		Name   *string `json:"name"`
+		Window int     `json:"window"`
+		Tab    int     `json:"tab"`
+		Active bool    `json:"active"`
+	}{}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if err := dec.Decode(&record); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data following tab")
+	}
+
+	if record.URL == nil {
+		return nil, errors.New("tab is missing a URL")
+	}
+	tabName := ""
+	if record.Name != nil {
+		tabName = *record.Name
 	}
-	return tInfo
+	return &tabInfo{
+		ID:          record.ID,
+		URL:         *record.URL,
+		Name:        tabName,
+		WindowIndex: record.Window,
+		TabIndex:    record.Tab,
+		Active:      record.Active,
+	}, nil
 }
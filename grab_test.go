@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func TestGrabTabs(tt *testing.T) {
+	tabs := fakeTabs("https://foo.com", "https://bar.com", "https://baz.com")
+
+	tests := map[string]struct {
+		window   int
+		maxTabs  int
+		prefix   string
+		format   string
+		template string
+		matcher  *tabMatcher
+		expected string
+	}{
+		"all tabs with default template": {
+			window:   activeWindow,
+			maxTabs:  100,
+			template: templateURL,
+			expected: "https://foo.com\nhttps://bar.com\nhttps://baz.com\n",
+		},
+		"max tabs less than number of tabs": {
+			window:   activeWindow,
+			maxTabs:  2,
+			template: templateURL,
+			expected: "https://foo.com\nhttps://bar.com\n",
+		},
+		"prefix": {
+			window:   activeWindow,
+			maxTabs:  100,
+			prefix:   "- ",
+			template: templateURL,
+			expected: "- https://foo.com\n- https://bar.com\n- https://baz.com\n",
+		},
+		"template with name": {
+			window:   activeWindow,
+			maxTabs:  1,
+			template: "[{{.Name}}]({{.URL}})",
+			expected: "[tab 1](https://foo.com)\n",
+		},
+		"second window": {
+			window:   2,
+			maxTabs:  100,
+			template: templateURL,
+			expected: "https://other.com\n",
+		},
+		"all windows separated by blank line": {
+			window:   allWindows,
+			maxTabs:  100,
+			template: templateURL,
+			expected: "https://foo.com\nhttps://bar.com\nhttps://baz.com\n\nhttps://other.com\n",
+		},
+		"template with window and tab indices": {
+			window:   allWindows,
+			maxTabs:  100,
+			template: "{{.WindowIndex}}.{{.TabIndex}} {{if .Active}}*{{end}}{{.URL}}",
+			expected: "1.1 *https://foo.com\n1.2 https://bar.com\n1.3 https://baz.com\n\n2.1 *https://other.com\n",
+		},
+		"json lines format": {
+			window:   allWindows,
+			maxTabs:  100,
+			prefix:   "- ",
+			format:   formatJSONL,
+			template: templateURL,
+			expected: `{"url":"https://foo.com","name":"tab 1","window":1,"tab":1,"active":true}` + "\n" +
+				`{"url":"https://bar.com","name":"tab 2","window":1,"tab":2,"active":false}` + "\n" +
+				`{"url":"https://baz.com","name":"tab 3","window":1,"tab":3,"active":false}` + "\n" +
+				`{"url":"https://other.com","name":"tab 1","window":2,"tab":1,"active":true}` + "\n",
+		},
+		"json format": {
+			window:   activeWindow,
+			maxTabs:  2,
+			format:   formatJSON,
+			template: "[{{.Name}}]({{.URL}})",
+			expected: "[\n" +
+				`{"url":"https://foo.com","name":"tab 1","window":1,"tab":1,"active":true},` + "\n" +
+				`{"url":"https://bar.com","name":"tab 2","window":1,"tab":2,"active":false}` + "\n]\n",
+		},
+		"onetab format": {
+			window:   allWindows,
+			maxTabs:  100,
+			prefix:   "- ",
+			format:   formatOneTab,
+			template: "[{{.Name}}]({{.URL}})",
+			expected: "https://foo.com | tab 1\nhttps://bar.com | tab 2\nhttps://baz.com | tab 3\n\nhttps://other.com | tab 1\n",
+		},
+		"json format without tabs": {
+			window:   3,
+			maxTabs:  100,
+			format:   formatJSON,
+			template: templateURL,
+			expected: "[]\n",
+		},
+		"matching tabs": {
+			window:   activeWindow,
+			maxTabs:  100,
+			template: templateURL,
+			matcher:  newSubstringMatcher([]string{"ba"}, nil),
+			expected: "https://bar.com\nhttps://baz.com\n",
+		},
+		"max tabs counts only matching tabs": {
+			window:   activeWindow,
+			maxTabs:  1,
+			template: templateURL,
+			matcher:  newSubstringMatcher([]string{"ba"}, nil),
+			expected: "https://bar.com\n",
+		},
+		"non-matching tabs of all windows": {
+			window:   allWindows,
+			maxTabs:  100,
+			template: templateURL,
+			matcher:  newSubstringMatcher(nil, []string{"bar"}),
+			expected: "https://foo.com\nhttps://baz.com\n\nhttps://other.com\n",
+		},
+		"json format without matching tabs": {
+			window:   activeWindow,
+			maxTabs:  100,
+			format:   formatJSON,
+			template: templateURL,
+			matcher:  newSubstringMatcher([]string{"xyz"}, nil),
+			expected: "[]\n",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			opts := &grabOptions{
+				commonOptions: &commonOptions{
+					driver:  newFakeDriver(tabs, fakeTabs("https://other.com")),
+					maxTabs: test.maxTabs,
+					prefix:  test.prefix,
+				},
+				urlWriter: newTestWriter(buf),
+				matcher:   test.matcher,
+				format:    test.format,
+				template:  test.template,
+				window:    test.window,
+			}
+			if err := grabTabs(opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := buf.String(); result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestGrabTabsNoLimit(t *testing.T) {
+	urls := []string{}
+	for i := 0; i < 150; i++ {
+		urls = append(urls, fmt.Sprintf("https://foo.com/%d", i))
+	}
+
+	buf := &bytes.Buffer{}
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver(fakeTabs(urls...)),
+			maxTabs: noTabLimit,
+		},
+		urlWriter: newTestWriter(buf),
+		template:  templateURL,
+		window:    activeWindow,
+	}
+	if err := grabTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := strings.Join(urls, "\n") + "\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("expected %d tabs, result %q", len(urls), result)
+	}
+}
+
+func TestGrabTabsInvalidTemplateRemovesOutput(t *testing.T) {
+	removed := false
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver(fakeTabs("https://foo.com")),
+			maxTabs: noTabLimit,
+		},
+		urlWriter: &writeCloseRemover{
+			Writer:  &bytes.Buffer{},
+			Closer:  func() error { return nil },
+			Remover: func() error { removed = true; return nil },
+		},
+		template: "{{.URL",
+		window:   activeWindow,
+	}
+	if err := grabTabs(opts); err == nil {
+		t.Fatal("expected error")
+	}
+	if !removed {
+		t.Error("expected output to be removed")
+	}
+}
+
+func TestGrabTabsJSONSpecialCharacters(t *testing.T) {
+	tab := &tabInfo{URL: "https://foo.com/?a=1&b=<2>", Name: `"Foo" \ Bar`}
+	buf := &bytes.Buffer{}
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver([]*tabInfo{tab}),
+			maxTabs: noTabLimit,
+		},
+		urlWriter: newTestWriter(buf),
+		format:    formatJSONL,
+		template:  templateURL,
+		window:    activeWindow,
+	}
+	if err := grabTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"url":"https://foo.com/?a=1&b=<2>","name":"\"Foo\" \\ Bar","window":1,"tab":1,"active":true}` + "\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+
+	result := &tabInfo{}
+	if err := json.Unmarshal(buf.Bytes(), result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.URL != tab.URL || result.Name != tab.Name {
+		t.Errorf("expected %v, result %v", tab, result)
+	}
+}
+
+func TestGrabTabsMultipleOutputs(t *testing.T) {
+	builder := multiWriteCloseRemoverBuilder{}
+	outputs := []*bytes.Buffer{{}, {}, {}}
+	for _, buf := range outputs {
+		builder.add(newTestWriter(buf))
+	}
+
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver(fakeTabs("https://foo.com", "https://bar.com"), fakeTabs("https://baz.com")),
+			maxTabs: noTabLimit,
+		},
+		urlWriter: builder.build(),
+		format:    formatJSON,
+		template:  templateURL,
+		window:    allWindows,
+	}
+	if err := grabTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tabs := []*tabInfo{}
+	if err := json.Unmarshal(outputs[0].Bytes(), &tabs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tabs) != 3 {
+		t.Errorf("expected 3 tabs, result %d", len(tabs))
+	}
+	for i, buf := range outputs[1:] {
+		if !bytes.Equal(buf.Bytes(), outputs[0].Bytes()) {
+			t.Errorf("expected output %d to be %q, result %q", i+2, outputs[0].String(), buf.String())
+		}
+	}
+}
+
+// streamingFakeDriver is a fakeDriver that streams tabs, checking that each tab has been written
+// before the next tab is streamed
+type streamingFakeDriver struct {
+	*fakeDriver
+	output *bytes.Buffer
+}
+
+func (d *streamingFakeDriver) StreamTabs(window int, maxTabs int, tabF func(*tabInfo) error) error {
+	tabs, err := d.ListTabs(window, maxTabs)
+	if err != nil {
+		return err
+	}
+	for i, tab := range tabs {
+		if i > 0 && !strings.Contains(d.output.String(), tabs[i-1].URL) {
+			return fmt.Errorf("tab %d was not written before tab %d was streamed", i, i+1)
+		}
+		if err := tabF(tab); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGrabTabsStreaming(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &grabOptions{
+		commonOptions: &commonOptions{
+			driver: &streamingFakeDriver{
+				fakeDriver: newFakeDriver(fakeTabs("https://foo.com", "https://bar.com", "https://baz.com")),
+				output:     buf,
+			},
+			maxTabs: noTabLimit,
+		},
+		urlWriter: newTestWriter(buf),
+		template:  templateURL,
+		window:    activeWindow,
+	}
+	if err := grabTabs(opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "https://foo.com\nhttps://bar.com\nhttps://baz.com\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+}
+
+func TestParseTabInfo(tt *testing.T) {
+	tests := map[string]struct {
+		raw         string
+		expected    *tabInfo
+		expectedErr bool
+	}{
+		"tab with position": {
+			raw:      `{"url":"https://foo.com","name":"Foo","window":2,"tab":3,"active":true}` + "\n",
+			expected: &tabInfo{URL: "https://foo.com", Name: "Foo", WindowIndex: 2, TabIndex: 3, Active: true},
+		},
+		"URL and name with commas": {
+			raw:      `{"url":"https://foo.com/?a=1,2","name":"Foo, Bar"}` + "\n",
+			expected: &tabInfo{URL: "https://foo.com/?a=1,2", Name: "Foo, Bar"},
+		},
+		"empty URL and name": {
+			raw:      `{"url":"","name":""}`,
+			expected: &tabInfo{URL: "", Name: ""},
+		},
+		"null name": {
+			raw:      `{"url":"https://foo.com","name":null}`,
+			expected: &tabInfo{URL: "https://foo.com", Name: ""},
+		},
+		"escaped non-ASCII and newline": {
+			raw:      `{"url":"https://foo.com/caf\u00e9","name":"Line 1\nLine 2 \ud83d\ude00"}`,
+			expected: &tabInfo{URL: "https://foo.com/café", Name: "Line 1\nLine 2 😀"},
+		},
+		"missing URL": {
+			raw:         `{"name":"Foo"}`,
+			expectedErr: true,
+		},
+		"null record": {
+			raw:         "null\n",
+			expectedErr: true,
+		},
+		"trailing data": {
+			raw:         `{"url":"https://foo.com","name":"Foo"} {"url":"https://bar.com","name":"Bar"}`,
+			expectedErr: true,
+		},
+		"invalid output": {
+			raw:         "execution error: Not authorized to send Apple events (-1743)\n",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, err := parseTabInfo([]byte(test.raw))
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func FuzzParseTabInfo(f *testing.F) {
+	f.Add("https://foo.com", "Foo")
+	f.Add("https://foo.com/?a=1,2&b=3", "Foo, Bar")
+	f.Add("https://foo.com", "Line 1\nLine 2")
+	f.Add("https://foo.com/\"quoted\"", "{\"name\": \"value\"}")
+	f.Add("https://foo.com/caf\u00e9", "😀 \u2028 \t")
+	f.Add("", "")
+
+	f.Fuzz(func(t *testing.T, url string, name string) {
+		if !utf8.ValidString(url) || !utf8.ValidString(name) {
+			t.Skip() // Browsers cannot produce invalid UTF-8
+		}
+
+		line := encodeTabInfoLikeScript(t, &tabInfo{URL: url, Name: name})
+		if bytes.Count(line, []byte("\n")) != 1 {
+			t.Fatalf("expected a single line, encoded %q", line)
+		}
+		result, err := parseTabInfo(line)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %q: %v", line, err)
+		}
+		if result.URL != url || result.Name != name {
+			t.Errorf("expected URL %q and name %q, result URL %q and name %q", url, name, result.URL, result.Name)
+		}
+	})
+}
+
+// encodeTabInfoLikeScript encodes a tab as the list tabs script does, including escaping non-ASCII
+// characters as UTF-16 code units and terminating the line with a newline
+func encodeTabInfoLikeScript(t *testing.T, tab *tabInfo) []byte {
+	b, err := json.Marshal(map[string]any{
+		"url":    tab.URL,
+		"name":   tab.Name,
+		"window": tab.WindowIndex,
+		"tab":    tab.TabIndex,
+		"active": tab.Active,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	for _, r := range string(b) {
+		if r < 0x7f {
+			out.WriteRune(r)
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(out, "\\u%04x", unit)
+		}
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+func newTestWriter(buf *bytes.Buffer) *writeCloseRemover {
+	builder := multiWriteCloseRemoverBuilder{}
+	builder.add(&writeCloseRemover{
+		Writer:  buf,
+		Closer:  func() error { return nil },
+		Remover: func() error { return nil },
+	})
+	return builder.build()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const journalFileName = "closed.jsonl"
+
+var errEmptyJournal = errors.New("no closed tabs to restore")
+
+// closedBatch is a group of tabs closed by a single close command
+type closedBatch struct {
+	ClosedAt time.Time  `json:"closed_at"`
+	Browser  string     `json:"browser"`
+	Tabs     []*tabInfo `json:"tabs"` // Tabs in the order of the window they were closed from
+}
+
+// closeJournal records closed tabs so that they can be reopened, writing each batch as a line of JSON
+// appended to a file so that recording a batch does not read or rewrite earlier batches
+type closeJournal struct {
+	path string
+}
+
+// newCloseJournal returns the journal stored in the tabgrab directory of the XDG state directory
+func newCloseJournal() (*closeJournal, error) {
+	dir, err := xdgStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &closeJournal{path: filepath.Join(dir, appName, journalFileName)}, nil
+}
+
+// record appends a batch of closed tabs to the journal
+func (j *closeJournal) record(batch *closedBatch) error {
+	line, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	// The journal contains browsing history, so it is only readable by the user
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// last returns the most recently recorded batch, or errEmptyJournal if there is none
+func (j *closeJournal) last() (*closedBatch, error) {
+	lines, err := j.lines()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errEmptyJournal
+	}
+
+	batch := &closedBatch{}
+	if err := json.Unmarshal(lines[len(lines)-1], batch); err != nil {
+		return nil, fmt.Errorf("failed to read journal entry %d: %w", len(lines), err)
+	}
+	return batch, nil
+}
+
+// removeLast removes the most recently recorded batch, replacing the journal in a single rename so that
+// an interrupted write does not lose earlier batches
+func (j *closeJournal) removeLast() error {
+	lines, err := j.lines()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return errEmptyJournal
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), journalFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range lines[:len(lines)-1] {
+		_, _ = w.Write(line)
+		_ = w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+// lines returns the non-empty lines of the journal, which is empty if it does not exist
+func (j *closeJournal) lines() ([][]byte, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := [][]byte{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) != 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
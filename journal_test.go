@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCloseJournal(t *testing.T) {
+	journal := &closeJournal{path: filepath.Join(t.TempDir(), appName, journalFileName)}
+
+	if _, err := journal.last(); err != errEmptyJournal {
+		t.Fatalf("expected error %v, result %v", errEmptyJournal, err)
+	}
+
+	closedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	batches := []*closedBatch{
+		{ClosedAt: closedAt, Browser: "chrome", Tabs: []*tabInfo{{URL: "https://foo.com", Name: "Foo", WindowIndex: 1, TabIndex: 2}}},
+		{ClosedAt: closedAt.Add(time.Minute), Browser: "safari", Tabs: []*tabInfo{{ID: "7", URL: "https://bar.com", Name: "Bar\nBaz", WindowIndex: 1, TabIndex: 1}}},
+	}
+	for _, batch := range batches {
+		if err := journal.record(batch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	info, err := os.Stat(journal.path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected permissions %o, result %o", 0o600, perm)
+	}
+
+	for i := len(batches) - 1; i >= 0; i-- {
+		result, err := journal.last()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result, batches[i]) {
+			t.Errorf("expected %v, result %v", batches[i], result)
+		}
+		if err := journal.removeLast(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := journal.last(); err != errEmptyJournal {
+		t.Errorf("expected error %v, result %v", errEmptyJournal, err)
+	}
+	if err := journal.removeLast(); err != errEmptyJournal {
+		t.Errorf("expected error %v, result %v", errEmptyJournal, err)
+	}
+}
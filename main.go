@@ -43,6 +43,36 @@ func main() {
 			os.Exit(1)
 		}
 
+	case dedupeCmd.Name():
+		if err := runDedupeCmd(dedupeCmd, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case saveCmd.Name():
+		if err := runSaveCmd(saveCmd, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case restoreCmd.Name():
+		if err := runRestoreCmd(restoreCmd, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case sessionsCmd.Name():
+		if err := runSessionsCmd(sessionsCmd, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case undoCmd.Name():
+		if err := runUndoCmd(undoCmd, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
 	case versionCmd.Name():
 		displayVersion()
 
@@ -69,6 +99,11 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "  %s:\t\t%s\n", grabCmdName, grabCmdDescription)
 	fmt.Fprintf(os.Stderr, "  %s:\t\t%s\n", tabCmdName, tabCmdDescription)
 	fmt.Fprintf(os.Stderr, "  %s:\t%s\n", closeCmdName, closeCmdDescription)
+	fmt.Fprintf(os.Stderr, "  %s:\t%s\n", dedupeCmdName, dedupeCmdDescription)
+	fmt.Fprintf(os.Stderr, "  %s:\t\t%s\n", saveCmdName, saveCmdDescription)
+	fmt.Fprintf(os.Stderr, "  %s:\t%s\n", restoreCmdName, restoreCmdDescription)
+	fmt.Fprintf(os.Stderr, "  %s:\t%s\n", sessionsCmdName, sessionsCmdDescription)
+	fmt.Fprintf(os.Stderr, "  %s:\t\t%s\n", undoCmdName, undoCmdDescription)
 	fmt.Fprintf(os.Stderr, "  %s:\t%s\n", versionCmdName, versionCmdDescription)
 	fmt.Fprintf(os.Stderr, "\nRun `%s <subcommand> -help` for subcommand usage and flags", appName)
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// matchFlags are the flags for selecting tabs shared by the commands that operate on a subset of tabs
+type matchFlags struct {
+	match         *string
+	nonMatch      *string
+	matchRegex    *string
+	nonMatchRegex *string
+	matchHost     *string
+	nonMatchHost  *string
+	query         *string
+}
+
+// attachMatchFlags attaches the flags for selecting tabs, describing the selected tabs as tabs to the
+// provided action
+func attachMatchFlags(fs *flag.FlagSet, action string) *matchFlags {
+	return &matchFlags{
+		match: fs.String(
+			"match",
+			"",
+			fmt.Sprintf("space delimited list of strings for matching tab URLs to %s", action),
+		),
+		nonMatch: fs.String(
+			"no-match",
+			"",
+			fmt.Sprintf("space delimited list of strings for non-matching tab URLs to %s", action),
+		),
+		matchRegex: fs.String(
+			"match-regex",
+			"",
+			fmt.Sprintf("regular expression for matching tab URLs to %s", action),
+		),
+		nonMatchRegex: fs.String(
+			"no-match-regex",
+			"",
+			fmt.Sprintf("regular expression for non-matching tab URLs to %s", action),
+		),
+		matchHost: fs.String(
+			"match-host",
+			"",
+			fmt.Sprintf("space delimited list of glob patterns, such as *.example.com, for matching tab URL hosts to %s", action),
+		),
+		nonMatchHost: fs.String(
+			"no-match-host",
+			"",
+			fmt.Sprintf("space delimited list of glob patterns, such as *.example.com, for non-matching tab URL hosts to %s", action),
+		),
+		query: fs.String(
+			"query",
+			"",
+			fmt.Sprintf("query for matching tabs to %s by URL, title, host, path or scheme, such as 'host:github.com -title:\"pull request\"', combined with other match flags", action),
+		),
+	}
+}
+
+// parse returns the matcher for the parsed flag values, compiling patterns once so that invalid patterns
+// are reported before listing tabs
+func (f *matchFlags) parse() (*tabMatcher, error) {
+	matcher := newSubstringMatcher(strings.Split(*f.match, " "), strings.Split(*f.nonMatch, " "))
+	if err := matcher.addRegex(*f.matchRegex, false); err != nil {
+		return nil, fmt.Errorf("invalid match-regex flag: %w", err)
+	}
+	if err := matcher.addRegex(*f.nonMatchRegex, true); err != nil {
+		return nil, fmt.Errorf("invalid no-match-regex flag: %w", err)
+	}
+	if err := matcher.addHostGlobs(strings.Split(*f.matchHost, " "), false); err != nil {
+		return nil, fmt.Errorf("invalid match-host flag: %w", err)
+	}
+	if err := matcher.addHostGlobs(strings.Split(*f.nonMatchHost, " "), true); err != nil {
+		return nil, fmt.Errorf("invalid no-match-host flag: %w", err)
+	}
+	if err := matcher.addQuery(*f.query); err != nil {
+		return nil, fmt.Errorf("invalid query flag: %w", err)
+	}
+	return matcher, nil
+}
+
+// tabMatcher matches a tab if it satisfies every match condition and none of the non-match conditions,
+// requiring at least one condition so that an empty matcher does not match every tab
+type tabMatcher struct {
+	match    []func(*tabInfo) bool
+	nonMatch []func(*tabInfo) bool
+}
+
+// newSubstringMatcher returns a matcher for tabs with URLs containing every match value and none of the
+// non-match values, ignoring empty values
+func newSubstringMatcher(matchVals []string, nonMatchVals []string) *tabMatcher {
+	m := &tabMatcher{}
+	m.addSubstrings(matchVals, false)
+	m.addSubstrings(nonMatchVals, true)
+	return m
+}
+
+func (m *tabMatcher) add(cond func(*tabInfo) bool, nonMatch bool) {
+	if nonMatch {
+		m.nonMatch = append(m.nonMatch, cond)
+	} else {
+		m.match = append(m.match, cond)
+	}
+}
+
+// addURL adds a condition on the URL of a tab
+func (m *tabMatcher) addURL(cond func(string) bool, nonMatch bool) {
+	m.add(func(tab *tabInfo) bool {
+		return cond(tab.URL)
+	}, nonMatch)
+}
+
+// addSubstrings adds a condition for each non-empty value that is satisfied if the URL contains the value
+func (m *tabMatcher) addSubstrings(vals []string, nonMatch bool) {
+	for _, val := range vals {
+		if len(val) == 0 {
+			continue
+		}
+		m.addURL(func(url string) bool {
+			return strings.Contains(url, val)
+		}, nonMatch)
+	}
+}
+
+// addRegex adds a condition, unless the expression is empty, that is satisfied if the URL matches the
+// regular expression
+func (m *tabMatcher) addRegex(expr string, nonMatch bool) error {
+	if expr == "" {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	m.addURL(re.MatchString, nonMatch)
+	return nil
+}
+
+// addHostGlobs adds a condition for each non-empty glob pattern that is satisfied if the host of the URL
+// matches the pattern, ignoring case
+func (m *tabMatcher) addHostGlobs(patterns []string, nonMatch bool) error {
+	for _, pattern := range patterns {
+		if len(pattern) == 0 {
+			continue
+		}
+		hostMatches, err := hostGlobMatcher(pattern)
+		if err != nil {
+			return err
+		}
+		m.addURL(hostMatches, nonMatch)
+	}
+	return nil
+}
+
+// hostGlobMatcher returns a function reporting whether the host of a URL matches a glob pattern, ignoring
+// case
+func hostGlobMatcher(pattern string) (func(rawURL string) bool, error) {
+	pattern = strings.ToLower(pattern)
+	// Validate the pattern as Match only reports a bad pattern when reaching the invalid syntax
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(rawURL string) bool {
+		matched, _ := path.Match(pattern, urlHost(rawURL))
+		return matched
+	}, nil
+}
+
+// addQuery adds a condition, unless the query is empty, that is satisfied if the tab matches the query
+func (m *tabMatcher) addQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	cond, err := parseQuery(query)
+	if err != nil {
+		return err
+	}
+	m.add(cond, false)
+	return nil
+}
+
+// empty reports whether the matcher has no conditions
+func (m *tabMatcher) empty() bool {
+	return len(m.match) == 0 && len(m.nonMatch) == 0
+}
+
+func (m *tabMatcher) matches(tab *tabInfo) bool {
+	if m.empty() {
+		return false
+	}
+	for _, cond := range m.match {
+		if !cond(tab) {
+			return false
+		}
+	}
+	for _, cond := range m.nonMatch {
+		if cond(tab) {
+			return false
+		}
+	}
+	return true
+}
+
+// urlHost returns the lowercase host of a URL without its port
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestURLMatcher(tt *testing.T) {
+	tests := map[string]struct {
+		matchVals     []string
+		matchRegex    string
+		nonMatchRegex string
+		matchHosts    []string
+		nonMatchHosts []string
+		expectedURLs  map[string]bool
+		expectedErr   bool
+	}{
+		"anchored regex": {
+			matchRegex: `^https://foo\.com/`,
+			expectedURLs: map[string]bool{
+				"https://foo.com/a":                 true,
+				"https://bar.com/?https://foo.com/": false,
+			},
+		},
+		"regex alternatives and path segment": {
+			matchRegex: `/(issues|pulls)/\d+$`,
+			expectedURLs: map[string]bool{
+				"https://github.com/foo/bar/issues/12": true,
+				"https://github.com/foo/bar/pulls/3":   true,
+				"https://github.com/foo/bar/issues":    false,
+			},
+		},
+		"non-matching regex": {
+			nonMatchRegex: `^https://`,
+			expectedURLs: map[string]bool{
+				"https://foo.com": false,
+				"http://foo.com":  true,
+			},
+		},
+		"regex with substring": {
+			matchVals:  []string{"bar"},
+			matchRegex: `^https://`,
+			expectedURLs: map[string]bool{
+				"https://bar.com": true,
+				"https://foo.com": false,
+				"http://bar.com":  false,
+			},
+		},
+		"host glob": {
+			matchHosts: []string{"*.atlassian.net"},
+			expectedURLs: map[string]bool{
+				"https://foo.atlassian.net/browse/FOO-1":    true,
+				"https://a.b.ATLASSIAN.net:443/":            true,
+				"https://atlassian.net/":                    false,
+				"https://foo.com/?redirect=x.atlassian.net": false,
+			},
+		},
+		"multiple host globs must all match": {
+			matchHosts: []string{"*.foo.com", "a*"},
+			expectedURLs: map[string]bool{
+				"https://a.foo.com": true,
+				"https://b.foo.com": false,
+			},
+		},
+		"non-matching host glob": {
+			nonMatchHosts: []string{"*.google.com", "github.com"},
+			expectedURLs: map[string]bool{
+				"https://mail.google.com": false,
+				"https://github.com/foo":  false,
+				"https://gitlab.com/foo":  true,
+			},
+		},
+		"host glob character class": {
+			matchHosts: []string{"server[0-9].local"},
+			expectedURLs: map[string]bool{
+				"http://server1.local:8080": true,
+				"http://serverx.local":      false,
+			},
+		},
+		"invalid regex": {
+			matchRegex:  `(foo`,
+			expectedErr: true,
+		},
+		"invalid host glob": {
+			nonMatchHosts: []string{"[foo"},
+			expectedErr:   true,
+		},
+		"no conditions": {
+			matchVals:    []string{""},
+			matchHosts:   []string{""},
+			expectedURLs: map[string]bool{"https://foo.com": false},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			m := newSubstringMatcher(test.matchVals, nil)
+			err := m.addRegex(test.matchRegex, false)
+			if err == nil {
+				err = m.addRegex(test.nonMatchRegex, true)
+			}
+			if err == nil {
+				err = m.addHostGlobs(test.matchHosts, false)
+			}
+			if err == nil {
+				err = m.addHostGlobs(test.nonMatchHosts, true)
+			}
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for url, expected := range test.expectedURLs {
+				if result := m.matches(&tabInfo{URL: url}); result != expected {
+					t.Errorf("expected %t for %s, result %t", expected, url, result)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchFlags(tt *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		expectedEmpty bool
+		expectedURLs  map[string]bool
+		expectedErr   bool
+	}{
+		"no flags": {
+			args:          []string{},
+			expectedEmpty: true,
+		},
+		"every flag": {
+			args: []string{
+				"-match", "foo bar", "-no-match", "baz",
+				"-match-regex", "^https://", "-no-match-regex", "/admin",
+				"-match-host", "*.com", "-no-match-host", "x.foo.com",
+				"-query", "-title:secret",
+			},
+			expectedURLs: map[string]bool{
+				"https://a.foo.com/bar":       true,
+				"http://a.foo.com/bar":        false,
+				"https://a.foo.com/bar/baz":   false,
+				"https://a.foo.com/bar/admin": false,
+				"https://x.foo.com/bar":       false,
+				"https://a.foo.org/bar":       false,
+			},
+		},
+		"invalid regex": {
+			args:        []string{"-match-regex", "(foo"},
+			expectedErr: true,
+		},
+		"invalid query": {
+			args:        []string{"-query", "(foo"},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			f := attachMatchFlags(fs, "test")
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			m, err := f.parse()
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.empty() != test.expectedEmpty {
+				t.Errorf("expected empty %t, result %t", test.expectedEmpty, m.empty())
+			}
+			for url, expected := range test.expectedURLs {
+				if result := m.matches(&tabInfo{URL: url, Name: "secret"}); result {
+					t.Errorf("expected no match for %s with excluded title", url)
+				}
+				if result := m.matches(&tabInfo{URL: url}); result != expected {
+					t.Errorf("expected %t for %s, result %t", expected, url, result)
+				}
+			}
+		})
+	}
+}
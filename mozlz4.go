@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Magic number of Mozilla's framed LZ4 files (.jsonlz4, .mozlz4)
+var mozLz4Magic = []byte("mozLz40\x00")
+
+// decodeMozLz4 decompresses data consisting of the mozLz40 magic number, the little-endian uint32
+// decompressed size, and a single LZ4 block
+func decodeMozLz4(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, mozLz4Magic) {
+		return nil, errors.New("invalid mozLz4 magic number")
+	}
+	data = data[len(mozLz4Magic):]
+	if len(data) < 4 {
+		return nil, errors.New("missing mozLz4 decompressed size")
+	}
+	size := binary.LittleEndian.Uint32(data[:4])
+
+	out, err := decodeLz4Block(data[4:], int(size))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != int(size) {
+		return nil, fmt.Errorf("decompressed size %d does not match expected size %d", len(out), size)
+	}
+	return out, nil
+}
+
+var errLz4Corrupt = errors.New("corrupt LZ4 block")
+
+// Maximum capacity preallocated for decompressed output, as the declared size of a file is untrusted
+const maxLz4Prealloc = 16 << 20
+
+// decodeLz4Block decompresses a raw LZ4 block (https://github.com/lz4/lz4/blob/dev/doc/lz4_Block_format.md)
+// of at most maxSize decompressed bytes
+func decodeLz4Block(src []byte, maxSize int) ([]byte, error) {
+	out := make([]byte, 0, min(maxSize, maxLz4Prealloc))
+	errTooLarge := fmt.Errorf("decompressed size exceeds expected size %d", maxSize)
+
+	i := 0
+	for i < len(src) {
+		token := src[i]
+		i++
+
+		// Literals
+		litLen, n, err := readLz4Length(src[i:], int(token>>4))
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		if litLen > len(src)-i {
+			return nil, errLz4Corrupt
+		}
+		if litLen > maxSize-len(out) {
+			return nil, errTooLarge
+		}
+		out = append(out, src[i:i+litLen]...)
+		i += litLen
+
+		// The last sequence contains only literals
+		if i == len(src) {
+			break
+		}
+
+		// Match
+		if len(src)-i < 2 {
+			return nil, errLz4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		if offset == 0 || offset > len(out) {
+			return nil, errLz4Corrupt
+		}
+		matchLen, n, err := readLz4Length(src[i:], int(token&0x0f))
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		matchLen += 4 // Minimum match length
+		if matchLen > maxSize-len(out) {
+			return nil, errTooLarge
+		}
+
+		// Copy byte by byte since the match may overlap the bytes being written
+		start := len(out) - offset
+		for j := 0; j < matchLen; j++ {
+			out = append(out, out[start+j])
+		}
+	}
+
+	return out, nil
+}
+
+// readLz4Length returns the length encoded by a token nibble and its extension bytes along with the
+// number of extension bytes read
+func readLz4Length(src []byte, nibble int) (int, int, error) {
+	length := nibble
+	if nibble != 0x0f {
+		return length, 0, nil
+	}
+	for i, b := range src {
+		length += int(b)
+		if b != 0xff {
+			return length, i + 1, nil
+		}
+	}
+	return 0, 0, errLz4Corrupt
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Preset formats for common document types, each with a parser so that written tabs can be read back
+const (
+	formatMarkdown = "markdown" // A Markdown list of links
+	formatOrg      = "org"      // An org-mode list of links
+	formatHTML     = "html"     // An HTML list of links for each window
+	formatCSV      = "csv"      // RFC 4180 CSV with a header row
+	formatTSV      = "tsv"      // Tab-separated values with a header row
+)
+
+// Parsers of the line-based formats, which separate windows with a blank line
+var presetLineParsers = map[string]templatedTabInfoParser{
+	formatOneTab:   parseOneTabLine,
+	formatMarkdown: parseMarkdownLine,
+	formatOrg:      parseOrgLine,
+}
+
+// Readers of the formats that are not line-based, which assign each tab a window
+var presetReaders = map[string]func(io.ReadCloser) ([]*tabInfo, error){
+	formatHTML: readHTMLTabs,
+	formatCSV:  readCSVTabs,
+	formatTSV:  readTSVTabs,
+}
+
+// Title of a link, which is the URL if a tab has no name as links require a title
+func linkTitle(tab *tabInfo) string {
+	if tab.Name == "" {
+		return tab.URL
+	}
+	return tab.Name
+}
+
+// Characters of a Markdown link destination that are escaped so that the destination ends at the
+// closing parenthesis, or at the closing angle bracket of a destination containing spaces
+var (
+	markdownURLReplacer        = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	markdownBracketURLReplacer = strings.NewReplacer(`\`, `\\`, `<`, `\<`, `>`, `\>`)
+)
+
+// markdownDestination returns a URL as a Markdown link destination, which must be enclosed in angle
+// brackets if it contains spaces
+func markdownDestination(url string) string {
+	if strings.Contains(url, " ") {
+		return "<" + markdownBracketURLReplacer.Replace(url) + ">"
+	}
+	return markdownURLReplacer.Replace(url)
+}
+
+// markdownWriteF writes a tab as a Markdown list item
+func markdownWriteF(w io.Writer) templatedTabInfoWriter {
+	return func(tab *tabInfo) error {
+		_, err := fmt.Fprintf(w, "- [%s](%s)\n", singleLine(templateMarkdownEscape(linkTitle(tab))), markdownDestination(tab.URL))
+		return err
+	}
+}
+
+// Markdown link in an optional list item, with a title and destination that may contain escapes
+var markdownLinkRegexp = regexp.MustCompile(`^\s*(?:[-*+]\s+)?\[((?:\\.|[^\\\]])*)\]\(\s*(<(?:\\.|[^\\>])*>|(?:\\.|[^\\])*?)\s*\)\s*$`)
+
+// Backslash escape of an ASCII punctuation character, which is the only escape in Markdown text
+var markdownEscapeRegexp = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+
+// parseMarkdownLine reads a tab from a Markdown link
+func parseMarkdownLine(line string) (*tabInfo, bool) {
+	match := markdownLinkRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	dest := match[2]
+	if strings.HasPrefix(dest, "<") {
+		dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	}
+	return &tabInfo{
+		URL:  markdownEscapeRegexp.ReplaceAllString(dest, "$1"),
+		Name: markdownEscapeRegexp.ReplaceAllString(match[1], "$1"),
+	}, true
+}
+
+// Characters of an org-mode link that are escaped so that the link ends at its closing brackets
+var orgLinkReplacer = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+// Brackets cannot be escaped in an org-mode link description so they are replaced with braces
+var orgDescriptionReplacer = strings.NewReplacer(`[`, `{`, `]`, `}`)
+
+// orgWriteF writes a tab as an org-mode list item
+func orgWriteF(w io.Writer) templatedTabInfoWriter {
+	return func(tab *tabInfo) error {
+		_, err := fmt.Fprintf(w, "- [[%s][%s]]\n", orgLinkReplacer.Replace(tab.URL), singleLine(orgDescriptionReplacer.Replace(linkTitle(tab))))
+		return err
+	}
+}
+
+// Org-mode link in an optional list item, with a link that may contain escapes and an optional description
+var orgLinkRegexp = regexp.MustCompile(`^\s*(?:[-+*]\s+)?\[\[((?:\\.|[^\\\]])*)\](?:\[(.*?)\])?\]\s*$`)
+
+// Backslash escape in an org-mode link
+var orgEscapeRegexp = regexp.MustCompile(`\\([\\\[\]])`)
+
+// parseOrgLine reads a tab from an org-mode link
+func parseOrgLine(line string) (*tabInfo, bool) {
+	match := orgLinkRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	return &tabInfo{
+		URL:  orgEscapeRegexp.ReplaceAllString(match[1], "$1"),
+		Name: match[2],
+	}, true
+}
+
+// singleLine replaces line breaks so that a value is written to a single line
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// htmlTabEncoder writes tabs as an HTML list of links for each window
+type htmlTabEncoder struct {
+	w          io.Writer
+	prevWindow int
+	started    bool
+}
+
+func (e *htmlTabEncoder) Encode(tab *tabInfo) error {
+	if !e.started || tab.WindowIndex != e.prevWindow {
+		start := "<ul>\n"
+		if e.started {
+			start = "</ul>\n" + start
+		}
+		if _, err := io.WriteString(e.w, start); err != nil {
+			return err
+		}
+	}
+	e.started = true
+	e.prevWindow = tab.WindowIndex
+
+	_, err := fmt.Fprintf(e.w, "  <li><a href=\"%s\">%s</a></li>\n", html.EscapeString(tab.URL), html.EscapeString(linkTitle(tab)))
+	return err
+}
+
+func (e *htmlTabEncoder) Finish() error {
+	end := "</ul>\n"
+	if !e.started {
+		end = "<ul>\n" + end
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// Tags that structure an HTML list of links, with the text following the tag
+var htmlListTagRegexp = regexp.MustCompile(`(?is)<(/?)(ul|ol|a)\b([^>]*)>([^<]*)`)
+
+// readHTMLTabs reads the links of an HTML document as tabs, assigning the links of each list the same
+// window so that each list is opened in its own window
+func readHTMLTabs(r io.ReadCloser) ([]*tabInfo, error) {
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML from reader: %w", err)
+	}
+
+	tabs := []*tabInfo{}
+	window := 0
+	for _, match := range htmlListTagRegexp.FindAllStringSubmatch(string(data), -1) {
+		closing, tag, attrs, text := match[1] == "/", strings.ToLower(match[2]), match[3], match[4]
+		if closing {
+			continue
+		}
+		if tag != "a" {
+			window++
+			continue
+		}
+		href := bookmarksHrefRegexp.FindStringSubmatch(attrs)
+		if href == nil {
+			continue
+		}
+		tabs = append(tabs, &tabInfo{
+			URL:         html.UnescapeString(strings.TrimSpace(href[1] + href[2] + href[3])),
+			Name:        html.UnescapeString(strings.TrimSpace(text)),
+			WindowIndex: window,
+		})
+	}
+	return tabs, nil
+}
+
+// Columns written by the csv and tsv formats
+var tableColumns = []string{"url", "name", "window", "tab", "active"}
+
+func tableRecord(tab *tabInfo) []string {
+	return []string{tab.URL, tab.Name, strconv.Itoa(tab.WindowIndex), strconv.Itoa(tab.TabIndex), strconv.FormatBool(tab.Active)}
+}
+
+// csvTabEncoder writes tabs as RFC 4180 CSV with a header row
+type csvTabEncoder struct {
+	w       *csv.Writer
+	started bool
+}
+
+func newCSVTabEncoder(w io.Writer) *csvTabEncoder {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	return &csvTabEncoder{w: cw}
+}
+
+func (e *csvTabEncoder) Encode(tab *tabInfo) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if err := e.w.Write(tableRecord(tab)); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvTabEncoder) Finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvTabEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.w.Write(tableColumns)
+}
+
+// readCSVTabs reads tabs from CSV with a header row containing a url column
+func readCSVTabs(r io.ReadCloser) ([]*tabInfo, error) {
+	defer r.Close()
+
+	cr := csv.NewReader(skipBOM(r))
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return []*tabInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := tableColumnIndices(header)
+	if err != nil {
+		return nil, err
+	}
+
+	tabs := []*tabInfo{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		tab, err := tableTab(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		tabs = append(tabs, tab)
+	}
+	return tabs, nil
+}
+
+// Characters of a TSV field that are escaped so that each field and record is separated
+var (
+	tsvEscaper   = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	tsvUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+)
+
+// tsvTabEncoder writes tabs as tab-separated values with a header row, escaping tabs, line breaks and
+// backslashes in fields with a backslash
+type tsvTabEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *tsvTabEncoder) Encode(tab *tabInfo) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	fields := tableRecord(tab)
+	for i, field := range fields {
+		fields[i] = tsvEscaper.Replace(field)
+	}
+	_, err := io.WriteString(e.w, strings.Join(fields, "\t")+"\n")
+	return err
+}
+
+func (e *tsvTabEncoder) Finish() error {
+	return e.start()
+}
+
+func (e *tsvTabEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	_, err := io.WriteString(e.w, strings.Join(tableColumns, "\t")+"\n")
+	return err
+}
+
+// readTSVTabs reads tabs from tab-separated values with a header row containing a url column
+func readTSVTabs(r io.ReadCloser) ([]*tabInfo, error) {
+	defer r.Close()
+
+	tabs := []*tabInfo{}
+	var columns map[string]int
+
+	br := bufio.NewReader(skipBOM(r))
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read tabs from reader: %w", err)
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if strings.TrimSpace(line) != "" {
+			fields := strings.Split(line, "\t")
+			for i, field := range fields {
+				fields[i] = tsvUnescaper.Replace(field)
+			}
+
+			if columns == nil {
+				columns, err = tableColumnIndices(fields)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				tab, tabErr := tableTab(fields, columns)
+				if tabErr != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, tabErr)
+				}
+				tabs = append(tabs, tab)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return tabs, nil
+}
+
+// tableColumnIndices returns the index of each known column of a header row
+func tableColumnIndices(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if slices.Contains(tableColumns, column) {
+			columns[column] = i
+		}
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("header row is missing a url column")
+	}
+	return columns, nil
+}
+
+// tableTab returns the tab of a record with columns at the provided indices
+func tableTab(record []string, columns map[string]int) (*tabInfo, error) {
+	field := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	tab := &tabInfo{URL: strings.TrimSpace(field("url")), Name: field("name")}
+	if window := strings.TrimSpace(field("window")); window != "" {
+		idx, err := strconv.Atoi(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q", window)
+		}
+		tab.WindowIndex = idx
+	}
+	return tab, nil
+}
+
+// skipBOM returns a reader that skips a UTF-8 byte order mark at the start of a reader
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	return br
+}
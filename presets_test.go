@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Tabs with names and URLs containing characters that must be escaped by each preset
+var presetTestTabs = [][]*tabInfo{
+	{
+		{URL: "https://foo.com/?a=1&b=(2)", Name: `Foo [Bar] (Baz) *qux* \ "quoted", <tag>`},
+		{URL: "https://bar.com/a b", Name: "Tab\tand\nnewline"},
+	},
+	{
+		{URL: "https://baz.com", Name: ""},
+	},
+}
+
+func TestPresetEncoders(tt *testing.T) {
+	tests := map[string]struct {
+		format   string
+		expected string
+	}{
+		"markdown": {
+			format: formatMarkdown,
+			expected: `- [Foo \[Bar\] \(Baz\) \*qux\* \\ "quoted", \<tag\>](https://foo.com/?a=1&b=\(2\))` + "\n" +
+				"- [Tab\tand newline](<https://bar.com/a b>)\n" +
+				"\n" +
+				"- [https://baz.com](https://baz.com)\n",
+		},
+		"org": {
+			format: formatOrg,
+			expected: `- [[https://foo.com/?a=1&b=(2)][Foo {Bar} (Baz) *qux* \ "quoted", <tag>]]` + "\n" +
+				"- [[https://bar.com/a b][Tab\tand newline]]\n" +
+				"\n" +
+				"- [[https://baz.com][https://baz.com]]\n",
+		},
+		"html": {
+			format: formatHTML,
+			expected: "<ul>\n" +
+				`  <li><a href="https://foo.com/?a=1&amp;b=(2)">Foo [Bar] (Baz) *qux* \ &#34;quoted&#34;, &lt;tag&gt;</a></li>` + "\n" +
+				"  <li><a href=\"https://bar.com/a b\">Tab\tand\nnewline</a></li>\n" +
+				"</ul>\n" +
+				"<ul>\n" +
+				"  <li><a href=\"https://baz.com\">https://baz.com</a></li>\n" +
+				"</ul>\n",
+		},
+		"csv": {
+			format: formatCSV,
+			expected: "url,name,window,tab,active\r\n" +
+				`https://foo.com/?a=1&b=(2),"Foo [Bar] (Baz) *qux* \ ""quoted"", <tag>",1,1,true` + "\r\n" +
+				"https://bar.com/a b,\"Tab\tand\r\nnewline\",1,2,false\r\n" +
+				"https://baz.com,,2,1,true\r\n",
+		},
+		"tsv": {
+			format: formatTSV,
+			expected: "url\tname\twindow\ttab\tactive\n" +
+				"https://foo.com/?a=1&b=(2)\tFoo [Bar] (Baz) *qux* \\\\ \"quoted\", <tag>\t1\t1\ttrue\n" +
+				"https://bar.com/a b\tTab\\tand\\nnewline\t1\t2\tfalse\n" +
+				"https://baz.com\t\t2\t1\ttrue\n",
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			opts := &grabOptions{
+				commonOptions: &commonOptions{
+					driver:  newFakeDriver(presetTestTabs...),
+					maxTabs: noTabLimit,
+					prefix:  "- ",
+				},
+				urlWriter: newTestWriter(buf),
+				format:    test.format,
+				template:  templateURL,
+				window:    allWindows,
+			}
+			if err := grabTabs(opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := buf.String(); result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestPresetRoundTrip(tt *testing.T) {
+	expectedWindows := [][]string{
+		{"https://foo.com/?a=1&b=(2)", "https://bar.com/a b"},
+		{"https://baz.com"},
+	}
+
+	for _, format := range []string{formatOneTab, formatMarkdown, formatOrg, formatHTML, formatCSV, formatTSV} {
+		tt.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			grabOpts := &grabOptions{
+				commonOptions: &commonOptions{
+					driver:  newFakeDriver(presetTestTabs...),
+					maxTabs: noTabLimit,
+				},
+				urlWriter: newTestWriter(buf),
+				format:    format,
+				template:  templateURL,
+				window:    allWindows,
+			}
+			if err := grabTabs(grabOpts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			driver := newFakeDriver()
+			tabsOpts := &tabsOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: noTabLimit,
+				},
+				urlReader: &urlReadCloser{
+					Reader: buf,
+					Closer: func() error { return nil },
+				},
+				format:               format,
+				template:             templateURL,
+				disablePrefixWarning: true,
+			}
+			if err := openTabs(tabsOpts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Each new window is opened in front of the previous windows
+			result := [][]string{}
+			for i := len(driver.windows) - 1; i >= 0; i-- {
+				result = append(result, driver.urls(i))
+			}
+			if !reflect.DeepEqual(result, expectedWindows) {
+				t.Errorf("expected %q, result %q", expectedWindows, result)
+			}
+		})
+	}
+}
+
+func TestPresetNameRoundTrip(tt *testing.T) {
+	tab := presetTestTabs[0][0]
+
+	tests := map[string]struct {
+		writeF   func(*bytes.Buffer) templatedTabInfoWriter
+		parseF   templatedTabInfoParser
+		expected string
+	}{
+		"markdown": {
+			writeF:   func(buf *bytes.Buffer) templatedTabInfoWriter { return markdownWriteF(buf) },
+			parseF:   parseMarkdownLine,
+			expected: tab.Name,
+		},
+		"org": {
+			writeF:   func(buf *bytes.Buffer) templatedTabInfoWriter { return orgWriteF(buf) },
+			parseF:   parseOrgLine,
+			expected: `Foo {Bar} (Baz) *qux* \ "quoted", <tag>`,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := test.writeF(buf)(tab); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, ok := test.parseF(strings.TrimSuffix(buf.String(), "\n"))
+			if !ok {
+				t.Fatalf("expected %q to be parsed", buf.String())
+			}
+			if result.URL != tab.URL {
+				t.Errorf("expected URL %q, result %q", tab.URL, result.URL)
+			}
+			if result.Name != test.expected {
+				t.Errorf("expected name %q, result %q", test.expected, result.Name)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownLine(tt *testing.T) {
+	tests := map[string]struct {
+		line       string
+		expected   *tabInfo
+		expectedOk bool
+	}{
+		"link": {
+			line:       "[Foo](https://foo.com)",
+			expected:   &tabInfo{URL: "https://foo.com", Name: "Foo"},
+			expectedOk: true,
+		},
+		"list item with asterisk": {
+			line:       "  * [Foo](https://foo.com/(bar))  ",
+			expected:   &tabInfo{URL: "https://foo.com/(bar)", Name: "Foo"},
+			expectedOk: true,
+		},
+		"angle bracket destination": {
+			line:       "- [Foo](<https://foo.com/a b>)",
+			expected:   &tabInfo{URL: "https://foo.com/a b", Name: "Foo"},
+			expectedOk: true,
+		},
+		"escaped title": {
+			line:       `- [\[Foo\] \_bar\_](https://foo.com)`,
+			expected:   &tabInfo{URL: "https://foo.com", Name: "[Foo] _bar_"},
+			expectedOk: true,
+		},
+		"not a link": {
+			line:       "https://foo.com",
+			expectedOk: false,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, ok := parseMarkdownLine(test.line)
+			if ok != test.expectedOk {
+				t.Fatalf("expected ok %t, result %t", test.expectedOk, ok)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseOrgLine(tt *testing.T) {
+	tests := map[string]struct {
+		line       string
+		expected   *tabInfo
+		expectedOk bool
+	}{
+		"link with description": {
+			line:       "- [[https://foo.com][Foo]]",
+			expected:   &tabInfo{URL: "https://foo.com", Name: "Foo"},
+			expectedOk: true,
+		},
+		"link without description": {
+			line:       "[[https://foo.com]]",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"escaped link": {
+			line:       `+ [[https://foo.com/\[a\]][Foo]]`,
+			expected:   &tabInfo{URL: "https://foo.com/[a]", Name: "Foo"},
+			expectedOk: true,
+		},
+		"not a link": {
+			line:       "[Foo](https://foo.com)",
+			expectedOk: false,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, ok := parseOrgLine(test.line)
+			if ok != test.expectedOk {
+				t.Fatalf("expected ok %t, result %t", test.expectedOk, ok)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestReadTableTabs(tt *testing.T) {
+	tests := map[string]struct {
+		input       string
+		readF       func(r *urlReadCloser) ([]*tabInfo, error)
+		expected    []*tabInfo
+		expectedErr bool
+	}{
+		"csv with reordered columns and byte order mark": {
+			input:    "\ufeffName,URL\n\" Foo, \"\"Bar\"\" \",https://foo.com\nBaz,https://baz.com\n",
+			readF:    func(r *urlReadCloser) ([]*tabInfo, error) { return readCSVTabs(r) },
+			expected: []*tabInfo{{URL: "https://foo.com", Name: ` Foo, "Bar" `}, {URL: "https://baz.com", Name: "Baz"}},
+		},
+		"csv without url column": {
+			input:       "name,link\nFoo,https://foo.com\n",
+			readF:       func(r *urlReadCloser) ([]*tabInfo, error) { return readCSVTabs(r) },
+			expectedErr: true,
+		},
+		"csv with invalid window": {
+			input:       "url,window\nhttps://foo.com,1\nhttps://bar.com,x\n",
+			readF:       func(r *urlReadCloser) ([]*tabInfo, error) { return readCSVTabs(r) },
+			expectedErr: true,
+		},
+		"empty csv": {
+			input:    "",
+			readF:    func(r *urlReadCloser) ([]*tabInfo, error) { return readCSVTabs(r) },
+			expected: []*tabInfo{},
+		},
+		"tsv with windows": {
+			input:    "url\twindow\r\nhttps://foo.com\t2\r\n\r\nhttps://bar.com\t1\r\n",
+			readF:    func(r *urlReadCloser) ([]*tabInfo, error) { return readTSVTabs(r) },
+			expected: []*tabInfo{{URL: "https://foo.com", WindowIndex: 2}, {URL: "https://bar.com", WindowIndex: 1}},
+		},
+		"tsv without url column": {
+			input:       "name\nFoo\n",
+			readF:       func(r *urlReadCloser) ([]*tabInfo, error) { return readTSVTabs(r) },
+			expectedErr: true,
+		},
+		"html lists": {
+			input:    "<p>Tabs</p><UL><li><A HREF='https://foo.com'>Foo &amp; Bar</A></li></UL><ol><li><a href=\"https://bar.com\">Bar</a></ol>",
+			readF:    func(r *urlReadCloser) ([]*tabInfo, error) { return readHTMLTabs(r) },
+			expected: []*tabInfo{{URL: "https://foo.com", Name: "Foo & Bar", WindowIndex: 1}, {URL: "https://bar.com", Name: "Bar", WindowIndex: 2}},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			r := &urlReadCloser{
+				Reader: strings.NewReader(test.input),
+				Closer: func() error { return nil },
+			}
+			result, err := test.readF(r)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
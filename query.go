@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Query fields
+const (
+	queryFieldURL    = "url"
+	queryFieldTitle  = "title"
+	queryFieldName   = "name" // Alias of title
+	queryFieldHost   = "host"
+	queryFieldPath   = "path"
+	queryFieldScheme = "scheme"
+)
+
+// queryFields are the fields a query term can be restricted to
+var queryFields = []string{queryFieldURL, queryFieldTitle, queryFieldName, queryFieldHost, queryFieldPath, queryFieldScheme}
+
+// Query operators
+const (
+	queryAnd = "AND"
+	queryOr  = "OR"
+	queryNot = "NOT"
+)
+
+type queryTokenKind int
+
+const (
+	queryTokenTerm queryTokenKind = iota
+	queryTokenNot
+	queryTokenOpen
+	queryTokenClose
+)
+
+type queryToken struct {
+	kind   queryTokenKind
+	text   string
+	quoted bool // Whether any part of the term was quoted, so that it is never an operator
+}
+
+// parseQuery parses a query into a condition on a tab. A query is a list of terms, each matching either
+// the URL or title of a tab or, if prefixed with a field and colon such as host:github.com, only that
+// field. Terms are combined with AND, which is implied between adjacent terms, OR, and NOT, which can also
+// be written as a leading -, in decreasing order of precedence, and grouped with parentheses. Values can
+// be quoted to include spaces and parentheses and are matched ignoring case, as substrings except for
+// host, which is a glob pattern, and scheme, which must be equal.
+func parseQuery(query string) (func(*tabInfo) bool, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+	p := &queryParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in query", tok.text)
+	}
+	return cond, nil
+}
+
+// lexQuery splits a query into tokens, removing quotes from terms
+func lexQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch c := runes[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenClose, text: ")"})
+			i++
+		case c == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, queryToken{kind: queryTokenNot, text: "-"})
+			i++
+		default:
+			tok := queryToken{kind: queryTokenTerm}
+			var text strings.Builder
+			inQuote := false
+			for ; i < len(runes); i++ {
+				c := runes[i]
+				if !inQuote && (unicode.IsSpace(c) || c == '(' || c == ')') {
+					break
+				}
+				switch {
+				case c == '"':
+					inQuote = !inQuote
+					tok.quoted = true
+				case c == '\\' && inQuote && i+1 < len(runes):
+					i++
+					text.WriteRune(runes[i])
+				default:
+					text.WriteRune(c)
+				}
+			}
+			if inQuote {
+				return nil, errors.New("unterminated quote in query")
+			}
+			tok.text = text.String()
+			if !tok.quoted && tok.text == queryNot {
+				tok.kind = queryTokenNot
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// isOperator reports whether the next token is the provided unquoted operator
+func (p *queryParser) isOperator(op string) bool {
+	tok, ok := p.peek()
+	return ok && tok.kind == queryTokenTerm && !tok.quoted && tok.text == op
+}
+
+func (p *queryParser) parseOr() (func(*tabInfo) bool, error) {
+	cond, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(queryOr) {
+		p.pos++
+		left := cond
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		cond = func(tab *tabInfo) bool {
+			return left(tab) || right(tab)
+		}
+	}
+	return cond, nil
+}
+
+func (p *queryParser) parseAnd() (func(*tabInfo) bool, error) {
+	cond, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.isOperator(queryAnd) {
+			p.pos++
+		} else if tok, ok := p.peek(); !ok || tok.kind == queryTokenClose || p.isOperator(queryOr) {
+			return cond, nil
+		}
+		left := cond
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		cond = func(tab *tabInfo) bool {
+			return left(tab) && right(tab)
+		}
+	}
+}
+
+func (p *queryParser) parseUnary() (func(*tabInfo) bool, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of query")
+	}
+	p.pos++
+
+	switch tok.kind {
+	case queryTokenNot:
+		cond, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(tab *tabInfo) bool {
+			return !cond(tab)
+		}, nil
+	case queryTokenOpen:
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok.kind != queryTokenClose {
+			return nil, errors.New("missing ) in query")
+		}
+		p.pos++
+		return cond, nil
+	case queryTokenClose:
+		return nil, errors.New("unexpected ) in query")
+	}
+
+	if !tok.quoted && (tok.text == queryAnd || tok.text == queryOr) {
+		return nil, fmt.Errorf("missing term before %s in query", tok.text)
+	}
+	return queryTerm(tok.text)
+}
+
+// queryTerm returns the condition for a single term, which is matched against the URL and title unless
+// it is prefixed with the name of a field
+func queryTerm(term string) (func(*tabInfo) bool, error) {
+	field, value, found := strings.Cut(term, ":")
+	field = strings.ToLower(field)
+	if !found || !slices.Contains(queryFields, field) {
+		value = strings.ToLower(term)
+		return func(tab *tabInfo) bool {
+			return strings.Contains(strings.ToLower(tab.URL), value) || strings.Contains(strings.ToLower(tab.Name), value)
+		}, nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %s in query", field)
+	}
+	value = strings.ToLower(value)
+
+	switch field {
+	case queryFieldURL:
+		return func(tab *tabInfo) bool {
+			return strings.Contains(strings.ToLower(tab.URL), value)
+		}, nil
+	case queryFieldTitle, queryFieldName:
+		return func(tab *tabInfo) bool {
+			return strings.Contains(strings.ToLower(tab.Name), value)
+		}, nil
+	case queryFieldHost:
+		hostMatches, err := hostGlobMatcher(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host pattern %q in query: %w", value, err)
+		}
+		return func(tab *tabInfo) bool {
+			return hostMatches(tab.URL)
+		}, nil
+	case queryFieldPath:
+		return func(tab *tabInfo) bool {
+			u, err := url.Parse(tab.URL)
+			return err == nil && strings.Contains(strings.ToLower(u.Path), value)
+		}, nil
+	default: // queryFieldScheme
+		return func(tab *tabInfo) bool {
+			u, err := url.Parse(tab.URL)
+			return err == nil && strings.ToLower(u.Scheme) == value
+		}, nil
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(tt *testing.T) {
+	tabs := map[string]*tabInfo{
+		"pull":     {URL: "https://github.com/foo/bar/pull/1", Name: "Fix bug by foo · Pull Request #1"},
+		"settings": {URL: "https://github.com/foo/bar/settings", Name: "Settings"},
+		"board":    {URL: "https://foo.atlassian.net/jira/software/projects/FOO/boards/1", Name: "FOO Sprint Board"},
+		"issue":    {URL: "https://foo.atlassian.net/browse/FOO-12", Name: "[FOO-12] Slow login"},
+		"insecure": {URL: "http://example.com/a(b)", Name: "Example"},
+	}
+
+	tests := map[string]struct {
+		query       string
+		expected    []string
+		expectedErr bool
+	}{
+		"bare term matches URL": {
+			query:    "atlassian",
+			expected: []string{"board", "issue"},
+		},
+		"bare term matches title ignoring case": {
+			query:    "slow",
+			expected: []string{"issue"},
+		},
+		"host": {
+			query:    "host:github.com",
+			expected: []string{"pull", "settings"},
+		},
+		"host glob": {
+			query:    "host:*.ATLASSIAN.net",
+			expected: []string{"board", "issue"},
+		},
+		"quoted title": {
+			query:    `title:"Pull Request"`,
+			expected: []string{"pull"},
+		},
+		"name is an alias of title": {
+			query:    "name:settings",
+			expected: []string{"settings"},
+		},
+		"negated path": {
+			query:    "host:github.com -path:/settings",
+			expected: []string{"pull"},
+		},
+		"scheme": {
+			query:    "scheme:http",
+			expected: []string{"insecure"},
+		},
+		"url": {
+			query:    "url:/browse/",
+			expected: []string{"issue"},
+		},
+		"every Jira tab except the sprint board": {
+			query:    `host:*.atlassian.net NOT title:"sprint board"`,
+			expected: []string{"issue"},
+		},
+		"explicit and": {
+			query:    "host:github.com AND title:settings",
+			expected: []string{"settings"},
+		},
+		"or": {
+			query:    "scheme:http OR title:settings",
+			expected: []string{"settings", "insecure"},
+		},
+		"and takes precedence over or": {
+			query:    "scheme:http OR host:github.com title:settings",
+			expected: []string{"settings", "insecure"},
+		},
+		"parentheses": {
+			query:    "(scheme:http OR host:github.com) -title:settings",
+			expected: []string{"pull", "insecure"},
+		},
+		"negated group": {
+			query:    "-(host:github.com OR scheme:http)",
+			expected: []string{"board", "issue"},
+		},
+		"quoted operator is a term": {
+			query:    `"OR"`,
+			expected: []string{},
+		},
+		"quoted parentheses": {
+			query:    `url:"a(b)"`,
+			expected: []string{"insecure"},
+		},
+		"unknown field is a bare term": {
+			query:    "https://github.com/foo",
+			expected: []string{"pull", "settings"},
+		},
+		"empty query": {
+			query:       " ",
+			expectedErr: true,
+		},
+		"unterminated quote": {
+			query:       `title:"foo`,
+			expectedErr: true,
+		},
+		"missing closing parenthesis": {
+			query:       "(foo OR bar",
+			expectedErr: true,
+		},
+		"unexpected closing parenthesis": {
+			query:       "foo)",
+			expectedErr: true,
+		},
+		"missing term before operator": {
+			query:       "OR foo",
+			expectedErr: true,
+		},
+		"missing term after operator": {
+			query:       "foo AND",
+			expectedErr: true,
+		},
+		"missing term after not": {
+			query:       "foo NOT",
+			expectedErr: true,
+		},
+		"missing field value": {
+			query:       "host:",
+			expectedErr: true,
+		},
+		"invalid host glob": {
+			query:       "host:[foo",
+			expectedErr: true,
+		},
+	}
+
+	order := []string{"pull", "settings", "board", "issue", "insecure"}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			cond, err := parseQuery(test.query)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := []string{}
+			for _, key := range order {
+				if cond(tabs[key]) {
+					result = append(result, key)
+				}
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+)
+
+// safariDriver implements tab operations for Safari
+type safariDriver struct {
+	*appleScriptDriver
+}
+
+func newSafariDriver(app *browserApplication, cfg *driverConfig) browserDriver {
+	return &safariDriver{
+		appleScriptDriver: &appleScriptDriver{
+			app:               app,
+			titleProperty:     "name",
+			activeTabFunction: "(window) => window.currentTab().index()",
+			verbose:           cfg.verbose,
+		},
+	}
+}
+
+func (d *safariDriver) OpenTabs(urls []string, browserArgs string) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	// Open a new window
+	cmd := exec.Command("open", "-na", d.app.cmdName, "--args", "--new-window", browserArgs)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if d.verbose {
+		log.Printf("executing: %s\n", cmd.String())
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+	}
+
+	// Give Safari a chance to get the new window 1
+	time.Sleep(500 * time.Millisecond)
+
+	scriptLayout := d.tellScript("tell window 1 to set URL of %s to \"%s\"")
+	tabIdx := "tab 1"
+	for _, url := range urls {
+		cmd := exec.Command("osascript", "-e", fmt.Sprintf(scriptLayout, tabIdx, url))
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if d.verbose {
+			log.Printf("executing: %s\n", cmd.String())
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+		}
+		tabIdx = "(make new tab)"
+	}
+
+	// Set last tab as active tab
+	return d.ActivateTab(len(urls))
+}
+
+func (d *safariDriver) ActivateTab(index int) error {
+	// Buffers to capture stdout and stderr
+	var stdout, stderr bytes.Buffer
+
+	script := d.tellScript(fmt.Sprintf("tell window 1 to set current tab to tab %d", index))
+	err := execOsaScript(script, &stdout, &stderr, d.verbose)
+	if err != nil {
+		return fmt.Errorf("failed to activate tab: %w", err)
+	}
+
+	return nil
+}
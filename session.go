@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	sessionsDirName   = "sessions"
+	sessionFileExt    = ".json"
+	sessionsRemoveArg = "rm"
+)
+
+var errSessionExists = errors.New("session already exists")
+
+// sessionNameRegexp matches names that are safe to use as file names
+var sessionNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// savedSession is a named group of tabs saved by the save command
+type savedSession struct {
+	Name    string     `json:"name"`
+	Browser string     `json:"browser"`
+	SavedAt time.Time  `json:"saved_at"`
+	Windows int        `json:"windows"`
+	Host    string     `json:"host"` // Host name of the machine the session was saved on
+	Tabs    []*tabInfo `json:"tabs"`
+}
+
+// sessionStore stores each session as a JSON file named for the session
+type sessionStore struct {
+	dir string
+}
+
+// newSessionStore returns the store in the tabgrab directory of the XDG data directory
+func newSessionStore() (*sessionStore, error) {
+	dir, err := xdgDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStore{dir: filepath.Join(dir, appName, sessionsDirName)}, nil
+}
+
+func (s *sessionStore) path(name string) (string, error) {
+	if !sessionNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid session name %q, names must contain only letters, digits, '.', '_' and '-' and must not start with '.'", name)
+	}
+	return filepath.Join(s.dir, name+sessionFileExt), nil
+}
+
+// savePath returns the path for saving a session, which must not exist unless overwrite is true
+func (s *sessionStore) savePath(name string, overwrite bool) (string, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("%w: %s", errSessionExists, name)
+	}
+	return path, nil
+}
+
+// save writes a session, replacing a session with the same name only if overwrite is true. The session is
+// written to a temporary file and renamed so that an interrupted save does not corrupt an existing session.
+func (s *sessionStore) save(session *savedSession, overwrite bool) error {
+	path, err := s.savePath(session.Name, overwrite)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Sessions contain browsing history, so they are only readable by the user
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, session.Name+sessionFileExt+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *sessionStore) load(name string) (*savedSession, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("session %s not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", name, err)
+	}
+
+	session := &savedSession{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", name, err)
+	}
+	session.Name = name
+	return session, nil
+}
+
+// list returns every saved session ordered by name and an error for each session that cannot be read,
+// which is skipped so that the remaining sessions can be listed and the unreadable sessions removed
+func (s *sessionStore) list() ([]*savedSession, []error, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*savedSession{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessions := []*savedSession{}
+	unreadable := []error{}
+	for _, entry := range entries {
+		name, isSession := strings.CutSuffix(entry.Name(), sessionFileExt)
+		if !isSession || entry.IsDir() || !sessionNameRegexp.MatchString(name) {
+			continue
+		}
+		session, err := s.load(name)
+		if err != nil {
+			unreadable = append(unreadable, err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Name < sessions[j].Name
+	})
+	return sessions, unreadable, nil
+}
+
+func (s *sessionStore) remove(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("session %s not found", name)
+	}
+	return err
+}
+
+// parseNameArg parses flags before and after a required name argument, returning the name
+func parseNameArg(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() == 0 {
+		return "", errors.New("session name required")
+	}
+	name := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 0 {
+		return "", fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+	return name, nil
+}
+
+func runSaveCmd(cmd *flag.FlagSet, args []string) error {
+	opts, err := parseSaveFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	err = saveSession(opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type saveOptions struct {
+	*commonOptions
+	store     *sessionStore
+	name      string
+	matcher   *tabMatcher // Save only matching tabs, or every tab if nil
+	window    int
+	overwrite bool
+}
+
+func parseSaveFlags(fs *flag.FlagSet, args []string) (*saveOptions, error) {
+	attachCommonFlags(fs)
+	attachProfileDirFlag(fs)
+	matchFlags := attachMatchFlags(fs, "save")
+
+	var (
+		allWindowsFlag = fs.Bool(
+			"all-windows",
+			false,
+			"save tabs from all browser windows",
+		)
+		window = fs.Int(
+			"window",
+			activeWindow,
+			"index of the browser window to save tabs from, ordered from front to back, ignored if -all-windows flag is used",
+		)
+		overwrite = fs.Bool(
+			"overwrite",
+			false,
+			"replace a saved session with the same name",
+		)
+	)
+
+	defaultUsage := fs.Usage
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "`%s <name>` %s\n\n", saveCmdName, saveCmdDescription)
+		defaultUsage()
+	}
+
+	name, err := parseNameArg(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := parseCommonOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := matchFlags.parse()
+	if err != nil {
+		return nil, err
+	}
+	if matcher.empty() {
+		matcher = nil
+	}
+
+	if *window < 1 {
+		return nil, errors.New("window index must be positive")
+	}
+	if *allWindowsFlag {
+		*window = allWindows
+	}
+
+	store, err := newSessionStore()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &saveOptions{
+		commonOptions: commonOpts,
+		store:         store,
+		name:          name,
+		matcher:       matcher,
+		window:        *window,
+		overwrite:     *overwrite,
+	}
+	return opts, nil
+}
+
+// saveSession grabs tabs in the json format and saves them with the metadata of the session
+func saveSession(opts *saveOptions) error {
+	// Check the name before grabbing tabs
+	if _, err := opts.store.savePath(opts.name, opts.overwrite); err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err := grabTabs(&grabOptions{
+		commonOptions: opts.commonOptions,
+		urlWriter: &writeCloseRemover{
+			Writer:  buf,
+			Closer:  func() error { return nil },
+			Remover: func() error { return nil },
+		},
+		matcher:  opts.matcher,
+		format:   formatJSON,
+		template: templateURL,
+		window:   opts.window,
+	})
+	if err != nil {
+		return err
+	}
+
+	tabs, err := readJSONTabs(io.NopCloser(buf), false)
+	if err != nil {
+		return fmt.Errorf("failed to read grabbed tabs: %w", err)
+	}
+	if len(tabs) == 0 {
+		return errors.New("no tabs to save")
+	}
+
+	browser := ""
+	if opts.browserApp != nil {
+		browser = opts.browserApp.name
+	}
+	host, _ := os.Hostname() // The host is informational, so it is empty if unknown
+
+	return opts.store.save(&savedSession{
+		Name:    opts.name,
+		Browser: browser,
+		SavedAt: time.Now(),
+		Windows: len(groupWindowURLs(tabs)),
+		Host:    host,
+		Tabs:    tabs,
+	}, opts.overwrite)
+}
+
+func runRestoreCmd(cmd *flag.FlagSet, args []string) error {
+	opts, err := parseRestoreFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	err = restoreSession(opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type restoreOptions struct {
+	*commonOptions
+	store       *sessionStore
+	name        string
+	browserArgs string
+}
+
+func parseRestoreFlags(fs *flag.FlagSet, args []string) (*restoreOptions, error) {
+	attachCommonFlags(fs)
+
+	var (
+		browserArgs = fs.String(
+			"browser-args",
+			setStringFlagDefault("", envVarBrowserArgs),
+			"optional space-delimited arguments to be passed to the browser",
+		)
+	)
+
+	defaultUsage := fs.Usage
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "`%s <name>` %s\n\n", restoreCmdName, restoreCmdDescription)
+		defaultUsage()
+	}
+
+	name, err := parseNameArg(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := parseCommonOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := newSessionStore()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &restoreOptions{
+		commonOptions: commonOpts,
+		store:         store,
+		name:          name,
+		browserArgs:   *browserArgs,
+	}
+	return opts, nil
+}
+
+// restoreSession opens the tabs of a saved session, each window in its own window
+func restoreSession(opts *restoreOptions) error {
+	session, err := opts.store.load(opts.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session.Tabs)
+	if err != nil {
+		return fmt.Errorf("failed to encode session tabs: %w", err)
+	}
+
+	return openTabs(&tabsOptions{
+		commonOptions: opts.commonOptions,
+		urlReader:     io.NopCloser(bytes.NewReader(data)),
+		format:        formatJSON,
+		browserArgs:   opts.browserArgs,
+	})
+}
+
+func runSessionsCmd(cmd *flag.FlagSet, args []string) error {
+	defaultUsage := cmd.Usage
+	cmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "`%s` %s\n\n", sessionsCmdName, sessionsCmdDescription)
+		defaultUsage()
+	}
+
+	err := cmd.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	store, err := newSessionStore()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cmd.NArg() == 0:
+		return listSessions(store, os.Stdout)
+	case cmd.Arg(0) == sessionsRemoveArg && cmd.NArg() == 2:
+		return store.remove(cmd.Arg(1))
+	case cmd.Arg(0) == sessionsRemoveArg:
+		return errors.New("a single session name required")
+	default:
+		return fmt.Errorf("unrecognized argument %s", cmd.Arg(0))
+	}
+}
+
+// listSessions writes the name, save time, browser, window and tab counts, and host of each session
+func listSessions(store *sessionStore, w io.Writer) error {
+	sessions, unreadable, err := store.list()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, err := range unreadable {
+		fmt.Fprintf(w, "Warning: skipping unreadable session: %v\n", err)
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "No saved sessions")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSAVED\tBROWSER\tWINDOWS\tTABS\tHOST")
+	for _, session := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			session.Name,
+			session.SavedAt.Local().Format("2006-01-02 15:04"),
+			session.Browser,
+			session.Windows,
+			len(session.Tabs),
+			session.Host,
+		)
+	}
+	return tw.Flush()
+}
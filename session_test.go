@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveAndRestoreSession(t *testing.T) {
+	store := &sessionStore{dir: filepath.Join(t.TempDir(), sessionsDirName)}
+	driver := newFakeDriver(fakeTabs("https://foo.com", "https://bar.com"), fakeTabs("https://baz.com"))
+	commonOpts := &commonOptions{
+		driver:  driver,
+		maxTabs: defaultMaxTabs,
+	}
+
+	saveOpts := &saveOptions{
+		commonOptions: commonOpts,
+		store:         store,
+		name:          "work",
+		window:        allWindows,
+	}
+	if err := saveSession(saveOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	session, err := store.load("work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.Windows != 2 {
+		t.Errorf("expected %d windows, result %d", 2, session.Windows)
+	}
+	expectedTabs := []*tabInfo{
+		{URL: "https://foo.com", Name: "tab 1", WindowIndex: 1, TabIndex: 1, Active: true},
+		{URL: "https://bar.com", Name: "tab 2", WindowIndex: 1, TabIndex: 2},
+		{URL: "https://baz.com", Name: "tab 1", WindowIndex: 2, TabIndex: 1, Active: true},
+	}
+	if !reflect.DeepEqual(session.Tabs, expectedTabs) {
+		t.Errorf("expected %v, result %v", expectedTabs, session.Tabs)
+	}
+	if session.SavedAt.IsZero() {
+		t.Error("expected save time")
+	}
+
+	// Saving again requires overwrite
+	if err := saveSession(saveOpts); !errors.Is(err, errSessionExists) {
+		t.Errorf("expected error %v, result %v", errSessionExists, err)
+	}
+	saveOpts.overwrite = true
+	saveOpts.matcher = newSubstringMatcher([]string{"ba"}, nil)
+	if err := saveSession(saveOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restoreOpts := &restoreOptions{
+		commonOptions: commonOpts,
+		store:         store,
+		name:          "work",
+	}
+	if err := restoreSession(restoreOpts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Each window of the session opens in its own window in front of the previously opened windows
+	expectedWindows := [][]string{{"https://baz.com"}, {"https://bar.com"}}
+	for i, expected := range expectedWindows {
+		if result := driver.urls(i); !reflect.DeepEqual(result, expected) {
+			t.Errorf("expected window %d %v, result %v", i+1, expected, result)
+		}
+	}
+}
+
+func TestSaveSessionWithoutTabs(t *testing.T) {
+	store := &sessionStore{dir: filepath.Join(t.TempDir(), sessionsDirName)}
+	opts := &saveOptions{
+		commonOptions: &commonOptions{
+			driver:  newFakeDriver(fakeTabs("https://foo.com")),
+			maxTabs: defaultMaxTabs,
+		},
+		store:   store,
+		name:    "empty",
+		matcher: newSubstringMatcher([]string{"xyz"}, nil),
+		window:  activeWindow,
+	}
+	if err := saveSession(opts); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "empty"+sessionFileExt)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no session file, result %v", err)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	store := &sessionStore{dir: filepath.Join(t.TempDir(), sessionsDirName)}
+	output := &bytes.Buffer{}
+
+	if err := listSessions(store, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected, result := "No saved sessions\n", output.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+
+	savedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)
+	for _, name := range []string{"work", "home.2"} {
+		err := store.save(&savedSession{
+			Name:    name,
+			Browser: "chrome",
+			SavedAt: savedAt,
+			Windows: 1,
+			Host:    "laptop",
+			Tabs:    []*tabInfo{{URL: "https://foo.com", WindowIndex: 1, TabIndex: 1}},
+		}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(store.dir, "work"+sessionFileExt))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected permissions %o, result %o", 0o600, perm)
+	}
+
+	output.Reset()
+	if err := listSessions(store, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "NAME    SAVED             BROWSER  WINDOWS  TABS  HOST\n" +
+		"home.2  2024-05-01 12:30  chrome   1        1     laptop\n" +
+		"work    2024-05-01 12:30  chrome   1        1     laptop\n"
+	if result := output.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+
+	if err := store.remove("work"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.remove("work"); err == nil {
+		t.Error("expected error removing a removed session")
+	}
+	if _, err := store.load("work"); err == nil {
+		t.Error("expected error loading a removed session")
+	}
+	sessions, unreadable, err := store.list()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Name != "home.2" {
+		t.Errorf("expected only session home.2, result %v", sessions)
+	}
+	if len(unreadable) != 0 {
+		t.Errorf("expected no unreadable sessions, result %v", unreadable)
+	}
+}
+
+func TestListSessionsUnreadable(t *testing.T) {
+	store := &sessionStore{dir: filepath.Join(t.TempDir(), sessionsDirName)}
+	err := store.save(&savedSession{
+		Name:    "work",
+		Browser: "chrome",
+		SavedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local),
+		Windows: 1,
+		Host:    "laptop",
+		Tabs:    []*tabInfo{{URL: "https://foo.com", WindowIndex: 1, TabIndex: 1}},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(store.dir, "broken"+sessionFileExt), []byte("{"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	if err := listSessions(store, output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Warning: skipping unreadable session: failed to read session broken: unexpected end of JSON input\n" +
+		"NAME  SAVED             BROWSER  WINDOWS  TABS  HOST\n" +
+		"work  2024-05-01 12:30  chrome   1        1     laptop\n"
+	if result := output.String(); result != expected {
+		t.Errorf("expected %q, result %q", expected, result)
+	}
+
+	if err := store.remove("broken"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSessionStorePath(tt *testing.T) {
+	tests := map[string]struct {
+		name        string
+		expectedErr bool
+	}{
+		"letters digits and punctuation": {
+			name: "work_2024-05.v1",
+		},
+		"empty": {
+			name:        "",
+			expectedErr: true,
+		},
+		"path separator": {
+			name:        "../work",
+			expectedErr: true,
+		},
+		"hidden": {
+			name:        ".work",
+			expectedErr: true,
+		},
+		"space": {
+			name:        "my work",
+			expectedErr: true,
+		},
+	}
+
+	store := &sessionStore{dir: "/sessions"}
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, err := store.path(test.name)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, result %s", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := "/sessions/" + test.name + sessionFileExt; result != expected {
+				t.Errorf("expected %s, result %s", expected, result)
+			}
+		})
+	}
+}
+
+func TestParseNameArg(tt *testing.T) {
+	tests := map[string]struct {
+		args         []string
+		expectedName string
+		expectedFlag bool
+		expectedErr  bool
+	}{
+		"name only": {
+			args:         []string{"work"},
+			expectedName: "work",
+		},
+		"flag before name": {
+			args:         []string{"-flag", "work"},
+			expectedName: "work",
+			expectedFlag: true,
+		},
+		"flag after name": {
+			args:         []string{"work", "-flag"},
+			expectedName: "work",
+			expectedFlag: true,
+		},
+		"missing name": {
+			args:        []string{"-flag"},
+			expectedErr: true,
+		},
+		"extra argument": {
+			args:        []string{"work", "home"},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			f := fs.Bool("flag", false, "")
+			result, err := parseNameArg(fs, test.args)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != test.expectedName {
+				t.Errorf("expected %s, result %s", test.expectedName, result)
+			}
+			if *f != test.expectedFlag {
+				t.Errorf("expected flag %t, result %t", test.expectedFlag, *f)
+			}
+		})
+	}
+}
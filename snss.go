@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+)
+
+// Chromium session files are a header followed by a log of commands that rebuild windows, tabs and
+// navigations (https://source.chromium.org/chromium/chromium/src/+/main:components/sessions/core/command_storage_backend.cc)
+
+var snssMagic = []byte("SNSS")
+
+// Session file versions
+const (
+	snssVersion1          = 1
+	snssVersionEncrypted  = 2
+	snssVersionWithMarker = 3
+	snssVersionEncrypted2 = 4
+)
+
+const (
+	snssHeaderSize         = 8 // Magic number and version
+	snssCommandSizeBytes   = 2
+	snssCommandIDSizeBytes = 1
+	snssWindowTypeNormal   = 0
+	snssInvalidNavIndex    = -1
+)
+
+// Session command IDs (https://source.chromium.org/chromium/chromium/src/+/main:components/sessions/core/session_service_commands.cc)
+const (
+	snssCmdSetTabWindow                     = 0
+	snssCmdSetTabIndexInWindow              = 2
+	snssCmdTabNavigationPathPrunedFromBack  = 5
+	snssCmdUpdateTabNavigation              = 6
+	snssCmdSetSelectedNavigationIndex       = 7
+	snssCmdSetSelectedTabInIndex            = 8
+	snssCmdSetWindowType                    = 9
+	snssCmdTabNavigationPathPrunedFromFront = 11
+	snssCmdTabClosed                        = 16
+	snssCmdWindowClosed                     = 17
+	snssCmdSetActiveWindow                  = 20
+	snssCmdTabNavigationPathPruned          = 24
+)
+
+type snssSession struct {
+	windows      []*snssWindow // In order of first appearance in the command log
+	activeWindow int32
+}
+
+type snssWindow struct {
+	id          int32
+	windowType  int32
+	selectedTab int32 // Index of the selected tab within the window
+	tabs        []*snssTab
+}
+
+type snssTab struct {
+	id          int32
+	windowID    int32
+	index       int32 // Index of the tab within its window
+	selectedNav int32
+	navs        map[int32]*snssNavigation
+}
+
+type snssNavigation struct {
+	url   string
+	title string
+}
+
+// parseSNSS rebuilds the windows and tabs of a Chromium session file
+func parseSNSS(data []byte) (*snssSession, error) {
+	if len(data) < snssHeaderSize || !bytes.HasPrefix(data, snssMagic) {
+		return nil, errors.New("invalid session file header")
+	}
+	switch version := binary.LittleEndian.Uint32(data[4:]); version {
+	case snssVersion1, snssVersionWithMarker:
+	case snssVersionEncrypted, snssVersionEncrypted2:
+		return nil, errors.New("encrypted session files are not supported")
+	default:
+		return nil, fmt.Errorf("unsupported session file version %d", version)
+	}
+
+	b := &snssBuilder{
+		windows: map[int32]*snssWindow{},
+		tabs:    map[int32]*snssTab{},
+	}
+
+	for i := snssHeaderSize; i < len(data); {
+		if len(data)-i < snssCommandSizeBytes {
+			break // Ignore a truncated trailing command written by a crashed browser
+		}
+		size := int(binary.LittleEndian.Uint16(data[i:]))
+		i += snssCommandSizeBytes
+		if size < snssCommandIDSizeBytes || size > len(data)-i {
+			break
+		}
+		id := data[i]
+		payload := data[i+snssCommandIDSizeBytes : i+size]
+		i += size
+
+		if err := b.apply(id, payload); err != nil {
+			return nil, fmt.Errorf("failed to parse command %d: %w", id, err)
+		}
+	}
+
+	return b.build(), nil
+}
+
+type snssBuilder struct {
+	windows      map[int32]*snssWindow
+	windowOrder  []int32
+	tabs         map[int32]*snssTab
+	activeWindow int32
+}
+
+func (b *snssBuilder) window(id int32) *snssWindow {
+	w, ok := b.windows[id]
+	if !ok {
+		w = &snssWindow{id: id, windowType: snssWindowTypeNormal}
+		b.windows[id] = w
+		b.windowOrder = append(b.windowOrder, id)
+	}
+	return w
+}
+
+func (b *snssBuilder) tab(id int32) *snssTab {
+	t, ok := b.tabs[id]
+	if !ok {
+		t = &snssTab{id: id, selectedNav: snssInvalidNavIndex, navs: map[int32]*snssNavigation{}}
+		b.tabs[id] = t
+	}
+	return t
+}
+
+func (b *snssBuilder) apply(id byte, payload []byte) error {
+	r := &snssReader{data: payload}
+
+	switch id {
+	case snssCmdSetTabWindow:
+		windowID, tabID := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.window(windowID)
+		b.tab(tabID).windowID = windowID
+
+	case snssCmdSetTabIndexInWindow:
+		tabID, index := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.tab(tabID).index = index
+
+	case snssCmdSetSelectedNavigationIndex:
+		tabID, index := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.tab(tabID).selectedNav = index
+
+	case snssCmdSetSelectedTabInIndex:
+		windowID, index := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.window(windowID).selectedTab = index
+
+	case snssCmdSetWindowType:
+		windowID, windowType := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.window(windowID).windowType = windowType
+
+	case snssCmdSetActiveWindow:
+		windowID := r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.activeWindow = windowID
+
+	case snssCmdTabClosed:
+		tabID := r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		delete(b.tabs, tabID)
+
+	case snssCmdWindowClosed:
+		windowID := r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		delete(b.windows, windowID)
+
+	case snssCmdUpdateTabNavigation:
+		// Navigations are serialized as a pickle prefixed with its payload size
+		r.uint32()
+		tabID, index := r.int32(), r.int32()
+		url := r.string()
+		title := r.string16()
+		if r.err != nil {
+			return r.err
+		}
+		b.tab(tabID).navs[index] = &snssNavigation{url: url, title: title}
+
+	case snssCmdTabNavigationPathPrunedFromBack:
+		tabID, count := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		tab := b.tab(tabID)
+		for index := range tab.navs {
+			if index >= count {
+				delete(tab.navs, index)
+			}
+		}
+
+	case snssCmdTabNavigationPathPrunedFromFront:
+		tabID, count := r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.tab(tabID).pruneNavigations(0, count)
+
+	case snssCmdTabNavigationPathPruned:
+		tabID, index, count := r.int32(), r.int32(), r.int32()
+		if r.err != nil {
+			return r.err
+		}
+		b.tab(tabID).pruneNavigations(index, count)
+	}
+
+	// All other commands do not affect the tabs and navigations of a session
+	return nil
+}
+
+// pruneNavigations removes count navigations starting at index and shifts later navigations down
+func (t *snssTab) pruneNavigations(index int32, count int32) {
+	navs := map[int32]*snssNavigation{}
+	for i, nav := range t.navs {
+		switch {
+		case i < index:
+			navs[i] = nav
+		case i >= index+count:
+			navs[i-count] = nav
+		}
+	}
+	t.navs = navs
+
+	if t.selectedNav >= index+count {
+		t.selectedNav -= count
+	} else if t.selectedNav >= index {
+		t.selectedNav = index - 1
+	}
+}
+
+func (b *snssBuilder) build() *snssSession {
+	session := &snssSession{activeWindow: b.activeWindow}
+
+	for _, id := range b.windowOrder {
+		if w, ok := b.windows[id]; ok {
+			session.windows = append(session.windows, w)
+		}
+	}
+	for _, tab := range b.tabs {
+		if w, ok := b.windows[tab.windowID]; ok && len(tab.navs) > 0 {
+			w.tabs = append(w.tabs, tab)
+		}
+	}
+	for _, w := range session.windows {
+		sort.SliceStable(w.tabs, func(i, j int) bool {
+			if w.tabs[i].index != w.tabs[j].index {
+				return w.tabs[i].index < w.tabs[j].index
+			}
+			return w.tabs[i].id < w.tabs[j].id
+		})
+	}
+
+	return session
+}
+
+// selectedNavigation returns the current navigation entry of a tab
+func (t *snssTab) selectedNavigation() *snssNavigation {
+	if nav, ok := t.navs[t.selectedNav]; ok {
+		return nav
+	}
+	// Fall back to the most recent navigation
+	var latest int32 = -1
+	for index := range t.navs {
+		if index > latest {
+			latest = index
+		}
+	}
+	return t.navs[latest]
+}
+
+// windowTabs returns the tabs of each normal window, with the active window first
+func (s *snssSession) windowTabs() [][]*tabInfo {
+	windows := []*snssWindow{}
+	for _, w := range s.windows {
+		if w.windowType != snssWindowTypeNormal || len(w.tabs) == 0 {
+			continue
+		}
+		if w.id == s.activeWindow {
+			windows = append([]*snssWindow{w}, windows...)
+		} else {
+			windows = append(windows, w)
+		}
+	}
+
+	windowTabs := [][]*tabInfo{}
+	for _, w := range windows {
+		tabs := []*tabInfo{}
+		for _, tab := range w.tabs {
+			nav := tab.selectedNavigation()
+			tabs = append(tabs, &tabInfo{
+				URL:    nav.url,
+				Name:   nav.title,
+				Active: tab.index == w.selectedTab,
+			})
+		}
+		windowTabs = append(windowTabs, tabs)
+	}
+	return windowTabs
+}
+
+// snssReader reads little-endian values aligned to 4 bytes as written by Chromium's base::Pickle
+type snssReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+var errSNSSTruncated = errors.New("truncated session command")
+
+func (r *snssReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = errSNSSTruncated
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// align skips padding so the next read starts on a 4-byte boundary
+func (r *snssReader) align() {
+	if pad := (4 - r.pos%4) % 4; pad > 0 && r.pos+pad <= len(r.data) {
+		r.pos += pad
+	}
+}
+
+func (r *snssReader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *snssReader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *snssReader) string() string {
+	n := r.int32()
+	b := r.next(int(n))
+	r.align()
+	return string(b)
+}
+
+func (r *snssReader) string16() string {
+	n := r.int32()
+	b := r.next(int(n) * 2)
+	r.align()
+	if b == nil {
+		return ""
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// sessionFileDriver lists tabs from a Chromium session file and uses the browser's driver to open tabs
+type sessionFileDriver struct {
+	browserDriver
+	path    string // Session file or profile directory
+	verbose bool
+}
+
+func newSessionFileDriver(path string, driver browserDriver, verbose bool) browserDriver {
+	return &sessionFileDriver{
+		browserDriver: driver,
+		path:          path,
+		verbose:       verbose,
+	}
+}
+
+func (d *sessionFileDriver) ListTabs(window int, maxTabs int) ([]*tabInfo, error) {
+	sessionFile, err := chromiumSessionFile(d.path)
+	if err != nil {
+		return nil, err
+	}
+	if d.verbose {
+		log.Printf("reading: %s\n", sessionFile)
+	}
+
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	session, err := parseSNSS(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session file %s: %w", sessionFile, err)
+	}
+
+	return selectWindowTabs(session.windowTabs(), window, maxTabs), nil
+}
+
+func (d *sessionFileDriver) CloseTabs(tabs []*tabInfo) error {
+	return fmt.Errorf("closing tabs from a session file: %w", errUnsupported)
+}
+
+func (d *sessionFileDriver) ActivateTab(index int) error {
+	return fmt.Errorf("activating tabs from a session file: %w", errUnsupported)
+}
+
+// chromiumSessionFile returns the path itself if it is a file or otherwise the most recent session
+// file of a profile directory
+func chromiumSessionFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	candidates, err := filepath.Glob(filepath.Join(path, "Sessions", "Session_*"))
+	if err != nil {
+		return "", err
+	}
+	candidates = append(candidates, filepath.Join(path, "Current Session")) // Used by older versions
+
+	sessionFile := mostRecentFile(candidates)
+	if sessionFile == "" {
+		return "", fmt.Errorf("no session file found in profile directory %s", path)
+	}
+	return sessionFile, nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// snssFile builds a session file from commands for testing
+type snssFile struct {
+	buf bytes.Buffer
+}
+
+func newSNSSFile(version uint32) *snssFile {
+	f := &snssFile{}
+	f.buf.Write(snssMagic)
+	_ = binary.Write(&f.buf, binary.LittleEndian, version)
+	return f
+}
+
+func (f *snssFile) command(id byte, payload []byte) *snssFile {
+	_ = binary.Write(&f.buf, binary.LittleEndian, uint16(len(payload)+1))
+	f.buf.WriteByte(id)
+	f.buf.Write(payload)
+	return f
+}
+
+func (f *snssFile) ints(id byte, vals ...int32) *snssFile {
+	payload := &bytes.Buffer{}
+	for _, v := range vals {
+		_ = binary.Write(payload, binary.LittleEndian, v)
+	}
+	return f.command(id, payload.Bytes())
+}
+
+func (f *snssFile) navigation(tabID int32, index int32, url string, title string) *snssFile {
+	pickle := &bytes.Buffer{}
+	pad := func() {
+		for pickle.Len()%4 != 0 {
+			pickle.WriteByte(0)
+		}
+	}
+	_ = binary.Write(pickle, binary.LittleEndian, tabID)
+	_ = binary.Write(pickle, binary.LittleEndian, index)
+	_ = binary.Write(pickle, binary.LittleEndian, int32(len(url)))
+	pickle.WriteString(url)
+	pad()
+	units := utf16.Encode([]rune(title))
+	_ = binary.Write(pickle, binary.LittleEndian, int32(len(units)))
+	_ = binary.Write(pickle, binary.LittleEndian, units)
+	pad()
+	_ = binary.Write(pickle, binary.LittleEndian, int32(0)) // Remaining fields are ignored
+
+	payload := &bytes.Buffer{}
+	_ = binary.Write(payload, binary.LittleEndian, uint32(pickle.Len()))
+	payload.Write(pickle.Bytes())
+	return f.command(snssCmdUpdateTabNavigation, payload.Bytes())
+}
+
+func (f *snssFile) bytes() []byte {
+	return f.buf.Bytes()
+}
+
+func TestParseSNSS(tt *testing.T) {
+	tests := map[string]struct {
+		data        []byte
+		expected    []*tabInfo
+		expectedErr bool
+	}{
+		"tabs ordered by index with selected navigation": {
+			data: newSNSSFile(snssVersionWithMarker).
+				ints(snssCmdSetTabWindow, 1, 10).
+				ints(snssCmdSetTabWindow, 1, 11).
+				ints(snssCmdSetTabIndexInWindow, 10, 1).
+				ints(snssCmdSetTabIndexInWindow, 11, 0).
+				navigation(10, 0, "https://foo.com", "Foo").
+				navigation(10, 1, "https://foo.com/page?a=1,2", "Foo, page").
+				ints(snssCmdSetSelectedNavigationIndex, 10, 1).
+				navigation(11, 0, "https://bar.com", "Bär ✓").
+				ints(snssCmdSetSelectedNavigationIndex, 11, 0).
+				bytes(),
+			expected: []*tabInfo{
+				{URL: "https://bar.com", Name: "Bär ✓", WindowIndex: 1, TabIndex: 1, Active: true},
+				{URL: "https://foo.com/page?a=1,2", Name: "Foo, page", WindowIndex: 1, TabIndex: 2},
+			},
+		},
+		"navigation pruned after going back": {
+			data: newSNSSFile(snssVersion1).
+				ints(snssCmdSetTabWindow, 1, 10).
+				navigation(10, 0, "https://foo.com", "Foo").
+				navigation(10, 1, "https://foo.com/a", "A").
+				navigation(10, 2, "https://foo.com/b", "B").
+				ints(snssCmdTabNavigationPathPruned, 10, 1, 2).
+				ints(snssCmdSetSelectedNavigationIndex, 10, 5).
+				bytes(),
+			expected: []*tabInfo{
+				{URL: "https://foo.com", Name: "Foo", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+		"navigations pruned from front": {
+			data: newSNSSFile(snssVersion1).
+				ints(snssCmdSetTabWindow, 1, 10).
+				navigation(10, 0, "https://foo.com", "Foo").
+				navigation(10, 1, "https://foo.com/a", "A").
+				ints(snssCmdSetSelectedNavigationIndex, 10, 1).
+				ints(snssCmdTabNavigationPathPrunedFromFront, 10, 1).
+				bytes(),
+			expected: []*tabInfo{
+				{URL: "https://foo.com/a", Name: "A", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+		"active window with closed tab": {
+			data: newSNSSFile(snssVersionWithMarker).
+				ints(snssCmdSetTabWindow, 1, 10).
+				navigation(10, 0, "https://foo.com", "Foo").
+				ints(snssCmdSetTabWindow, 2, 20).
+				ints(snssCmdSetTabWindow, 2, 21).
+				ints(snssCmdSetTabIndexInWindow, 21, 1).
+				navigation(20, 0, "https://bar.com", "Bar").
+				navigation(21, 0, "https://baz.com", "Baz").
+				ints(snssCmdTabClosed, 21, 0, 0, 0).
+				ints(snssCmdSetActiveWindow, 2).
+				bytes(),
+			expected: []*tabInfo{
+				{URL: "https://bar.com", Name: "Bar", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+		"closed and popup windows are skipped": {
+			data: newSNSSFile(snssVersionWithMarker).
+				ints(snssCmdSetTabWindow, 1, 10).
+				navigation(10, 0, "https://foo.com", "Foo").
+				ints(snssCmdWindowClosed, 1, 0, 0, 0).
+				ints(snssCmdSetTabWindow, 2, 20).
+				ints(snssCmdSetWindowType, 2, 1).
+				navigation(20, 0, "https://popup.com", "Popup").
+				ints(snssCmdSetTabWindow, 3, 30).
+				navigation(30, 0, "https://bar.com", "Bar").
+				bytes(),
+			expected: []*tabInfo{
+				{URL: "https://bar.com", Name: "Bar", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+		"truncated trailing command is ignored": {
+			data: append(newSNSSFile(snssVersion1).
+				ints(snssCmdSetTabWindow, 1, 10).
+				navigation(10, 0, "https://foo.com", "Foo").
+				bytes(), 0x20, 0x00, snssCmdSetTabWindow),
+			expected: []*tabInfo{
+				{URL: "https://foo.com", Name: "Foo", WindowIndex: 1, TabIndex: 1, Active: true},
+			},
+		},
+		"truncated command payload": {
+			data:        newSNSSFile(snssVersion1).ints(snssCmdSetTabWindow, 1).bytes(),
+			expectedErr: true,
+		},
+		"encrypted session file": {
+			data:        newSNSSFile(snssVersionEncrypted).bytes(),
+			expectedErr: true,
+		},
+		"invalid magic number": {
+			data:        []byte("SNSX\x01\x00\x00\x00"),
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			session, err := parseSNSS(test.data)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result := selectWindowTabs(session.windowTabs(), activeWindow, defaultMaxTabs)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestSNSSWindowTabs(t *testing.T) {
+	data := newSNSSFile(snssVersionWithMarker).
+		ints(snssCmdSetTabWindow, 1, 10).
+		navigation(10, 0, "https://foo.com", "Foo").
+		ints(snssCmdSetTabWindow, 2, 20).
+		ints(snssCmdSetTabWindow, 2, 21).
+		ints(snssCmdSetTabIndexInWindow, 21, 1).
+		navigation(20, 0, "https://bar.com", "Bar").
+		navigation(21, 0, "https://baz.com", "Baz").
+		ints(snssCmdSetSelectedTabInIndex, 2, 1).
+		ints(snssCmdSetActiveWindow, 2).
+		bytes()
+
+	session, err := parseSNSS(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []*tabInfo{
+		{URL: "https://bar.com", Name: "Bar", WindowIndex: 1, TabIndex: 1},
+		{URL: "https://baz.com", Name: "Baz", WindowIndex: 1, TabIndex: 2, Active: true},
+		{URL: "https://foo.com", Name: "Foo", WindowIndex: 2, TabIndex: 1, Active: true},
+	}
+	if result := selectWindowTabs(session.windowTabs(), allWindows, defaultMaxTabs); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+}
+
+func TestSessionFileDriverProfileDir(t *testing.T) {
+	profileDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(profileDir, "Sessions"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	data := newSNSSFile(snssVersionWithMarker).
+		ints(snssCmdSetTabWindow, 1, 10).
+		navigation(10, 0, "https://foo.com", "Foo").
+		bytes()
+	if err := os.WriteFile(filepath.Join(profileDir, "Sessions", "Session_13400000000000000"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	driver := newChromiumDriver(browserApplications[browserNameChrome], &driverConfig{profileDir: profileDir})
+	result, err := driver.ListTabs(activeWindow, defaultMaxTabs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []*tabInfo{{URL: "https://foo.com", Name: "Foo", WindowIndex: 1, TabIndex: 1, Active: true}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, result %v", expected, result)
+	}
+
+	if err := driver.CloseTabs([]*tabInfo{{URL: "https://foo.com", TabIndex: 1}}); err == nil {
+		t.Error("expected error closing tabs from a session file")
+	}
+}
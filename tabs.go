@@ -1,16 +1,15 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"net/url"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
-	"time"
 )
 
 func runTabsCmd(cmd *flag.FlagSet, args []string) error {
@@ -30,8 +29,13 @@ func runTabsCmd(cmd *flag.FlagSet, args []string) error {
 type tabsOptions struct {
 	*commonOptions
 	urlReader            io.ReadCloser
+	format               string
+	template             string
+	folder               string
 	browserArgs          string
 	disablePrefixWarning bool
+	input                io.Reader // Input for confirming warnings
+	output               io.Writer // Output for warnings
 }
 
 func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
@@ -41,12 +45,12 @@ func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
 		urlList = fs.String(
 			"urls",
 			"",
-			fmt.Sprintf("newline-delimited list of URLs, typically the output from the %s command, ignored if -clipboard flag is used", grabCmdName),
+			fmt.Sprintf("newline-delimited list of URLs, typically the output from the %s command, with blank lines separating windows, ignored if -clipboard flag is used", grabCmdName),
 		)
 		urlFile = fs.String(
 			"file",
 			"",
-			"path to file containing newline-delimited list of URLs, ignored if -urls or -clipboard flag is used",
+			fmt.Sprintf("path to file containing newline-delimited list of URLs, with blank lines separating windows, or %s to read from stdin, ignored if -urls or -clipboard flag is used", stdinFile),
 		)
 		browserArgs = fs.String(
 			"browser-args",
@@ -56,7 +60,22 @@ func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
 		disablePrefixWarning = fs.Bool(
 			"disable-prefix-warning",
 			false,
-			"disables warning for potentially mismatched prefix flag and URL prefixes (default false)",
+			"disables warning and prompt for lines that do not match the prefix and template flags, which are skipped, and for URLs that potentially do not match them (default false)",
+		)
+		template = fs.String(
+			"template",
+			setStringFlagDefault(defaultTemplate, envVarTemplate),
+			fmt.Sprintf("format of each line specifying tab URL with {{.URL}} and tab name with {{.Name}}, typically the template used by the %s command", grabCmdName),
+		)
+		format = fs.String(
+			"format",
+			formatText,
+			fmt.Sprintf("input format, one of %v, where %s reads each line with the template and other formats read tabs written by the %s command in the same format, opening each window, bookmark folder, list or blank-line-separated group of tabs in its own window", outputFormats, formatText, grabCmdName),
+		)
+		folder = fs.String(
+			"folder",
+			"",
+			fmt.Sprintf("name of the bookmark folder to read tabs from, including its subfolders, used with the %s format (default all folders)", formatHTMLBookmarks),
 		)
 	)
 
@@ -76,6 +95,10 @@ func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
 		return nil, err
 	}
 
+	if !slices.Contains(outputFormats, *format) {
+		return nil, fmt.Errorf("format must be one of %v", outputFormats)
+	}
+
 	var urlReader *urlReadCloser
 	switch {
 	case commonOpts.clipboard:
@@ -88,6 +111,11 @@ func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
 			Reader: strings.NewReader(*urlList),
 			Closer: func() error { return nil },
 		}
+	case *urlFile == stdinFile:
+		urlReader = &urlReadCloser{
+			Reader: os.Stdin,
+			Closer: func() error { return nil },
+		}
 	case *urlFile != "":
 		f, err := os.Open(*urlFile)
 		if err != nil {
@@ -104,12 +132,20 @@ func parseTabsFlags(fs *flag.FlagSet, args []string) (*tabsOptions, error) {
 	opts := &tabsOptions{
 		commonOptions:        commonOpts,
 		urlReader:            urlReader,
+		format:               *format,
+		template:             *template,
+		folder:               *folder,
 		browserArgs:          *browserArgs,
 		disablePrefixWarning: *disablePrefixWarning,
+		input:                os.Stdin,
+		output:               os.Stdout,
 	}
 	return opts, nil
 }
 
+// File name for reading URLs from stdin
+const stdinFile = "-"
+
 type urlReadCloser struct {
 	io.Reader
 	Closer func() error
@@ -120,163 +156,165 @@ func (u *urlReadCloser) Close() error {
 }
 
 func openTabs(opts *tabsOptions) error {
-	urls, prefixes, err := readURLs(opts.urlReader, func(url string) string {
-		return cleanURL(url, opts.prefix)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to read URLs: %w", err)
-	}
-
-	if !opts.disablePrefixWarning && warnMismatchingPrefixes(prefixes, opts.prefix) {
-		return errUserAbort
-	}
-
-	if len(urls) == 0 {
-		return errors.New("no URLs provided")
-	}
-
-	switch opts.browserApp.name {
-	case browserNameChrome, browserNameBrave:
-		err := openTabsChromium(opts, urls)
+	var windows [][]string
+	switch opts.format {
+	case formatJSON, formatJSONL:
+		tabs, err := readJSONTabs(opts.urlReader, opts.format == formatJSONL)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to read tabs: %w", err)
 		}
-	case browserNameSafari:
-		err := openTabsSafari(opts, urls)
+		windows = groupWindowURLs(tabs)
+	case formatHTMLBookmarks:
+		tabs, err := readBookmarkTabs(opts.urlReader, opts.folder)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to read bookmarks: %w", err)
 		}
+		windows = groupWindowURLs(tabs)
+	case formatOneTab, formatMarkdown, formatOrg:
+		var unmatched []int
+		var err error
+		windows, unmatched, err = readURLs(opts.urlReader, presetLineParsers[opts.format])
+		if err != nil {
+			return fmt.Errorf("failed to read URLs: %w", err)
+		}
+		if len(unmatched) != 0 {
+			return fmt.Errorf("failed to read URLs: line %d does not match the %s format", unmatched[0], opts.format)
+		}
+	case formatHTML, formatCSV, formatTSV:
+		tabs, err := presetReaders[opts.format](opts.urlReader)
+		if err != nil {
+			return fmt.Errorf("failed to read tabs: %w", err)
+		}
+		windows = groupWindowURLs(tabs)
 	default:
-		return fmt.Errorf("unrecognized browser: %s", opts.browserApp.name)
-	}
+		parseF, err := buildTemplateParseF(fmt.Sprintf("%s%s", opts.prefix, opts.template))
+		if err != nil {
+			return fmt.Errorf("failed to construct parser template: %w", err)
+		}
 
-	return nil
-}
+		var unmatched []int
+		windows, unmatched, err = readURLs(opts.urlReader, parseF)
+		if err != nil {
+			return fmt.Errorf("failed to read URLs: %w", err)
+		}
 
-func openTabsChromium(opts *tabsOptions, urls []string) error {
-	// Buffers to capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-
-	newWindowArg := "--new-window" // Open the first URL in a new window
-	for _, url := range urls {
-		cmd := exec.Command("open", "-na", opts.browserApp.cmdName, "--args", newWindowArg, opts.browserArgs, url)
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if opts.verbose {
-			log.Printf("executing: %s\n", cmd.String())
+		// Lines that do not match are skipped, warning first unless warnings are disabled
+		if !opts.disablePrefixWarning && warnUnmatchedLines(unmatched, opts) {
+			return errUserAbort
 		}
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+		if !opts.disablePrefixWarning && warnInvalidURLs(windows, opts) {
+			return errUserAbort
 		}
-		newWindowArg = "" // Open the remaining URLs as tabs within the window
 	}
 
-	return nil
-}
-
-func openTabsSafari(opts *tabsOptions, urls []string) error {
-	// Buffers to capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-
-	// Open a new window
-	cmd := exec.Command("open", "-na", opts.browserApp.cmdName, "--args", "--new-window", opts.browserArgs)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if opts.verbose {
-		log.Printf("executing: %s\n", cmd.String())
-	}
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+	if len(windows) == 0 {
+		return errors.New("no URLs provided")
 	}
 
-	// Give Safari a chance to get the new window 1
-	time.Sleep(500 * time.Millisecond)
-
-	scriptLayout := "tell application \"" + opts.browserApp.cmdName + "\" to tell window 1 to set URL of %s to \"%s\""
-	tabIdx := "tab 1"
-	for _, url := range urls {
-		cmd := exec.Command("osascript", "-e", fmt.Sprintf(scriptLayout, tabIdx, url))
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		if opts.verbose {
-			log.Printf("executing: %s\n", cmd.String())
-		}
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("%s\n%v\n", stderr.String(), err)
+	// Open each group of URLs in its own window
+	for _, urls := range windows {
+		err := opts.driver.OpenTabs(urls, opts.browserArgs)
+		if err != nil {
+			return err
 		}
-		tabIdx = "(make new tab)"
-	}
-
-	// Set last tab as active tab
-	lastTabScript := "tell application \"" + opts.browserApp.cmdName + "\" to tell window 1 to set current tab to last tab"
-	cmd = exec.Command("osascript", "-e", lastTabScript)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if opts.verbose {
-		log.Printf("executing: %s\n", cmd.String())
-	}
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%s\n%v\n", stderr.String(), err)
 	}
 
 	return nil
 }
 
-func readURLs(r io.ReadCloser, cleanF func(string) string) ([]string, prefixSet, error) {
-	urls := []string{}
-	prefixes := newPrefixSet() // Track prefixes to detect potential mismatches
-
-	raw := make([]byte, 1024*1024)
-	_, err := r.Read(raw)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read URLs from reader: %w", err)
-	}
+// readURLs returns groups of URLs separated by blank lines, with each group corresponding to a window,
+// and the numbers of the lines that do not match the template, which are skipped. Input is read line by
+// line so that it is not limited in size, and the URL of each line is parsed with the template that
+// wrote it.
+func readURLs(r io.ReadCloser, parseF templatedTabInfoParser) ([][]string, []int, error) {
 	defer r.Close()
-	rawURLs := string(raw[:])
 
-	for _, url := range strings.Split(rawURLs, "\n") {
-		if len(url) == 0 {
-			continue
+	windows := [][]string{}
+	urls := []string{}
+	unmatched := []int{}
+
+	// Read with bufio.Reader rather than bufio.Scanner as Scanner limits the length of a line
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, fmt.Errorf("failed to read URLs from reader: %w", err)
+		}
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		line = strings.Trim(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), "\x00")
+
+		if strings.TrimSpace(line) == "" {
+			// A blank line ends the current window
+			if len(urls) > 0 {
+				windows = append(windows, urls)
+				urls = []string{}
+			}
+		} else {
+			// A line without a URL is unmatched so that it is not skipped without a warning
+			tab, ok := parseF(line)
+			if !ok || tab.URL == "" {
+				unmatched = append(unmatched, lineNum)
+			} else {
+				urls = append(urls, tab.URL)
+			}
 		}
 
-		prefixes.addFrom(url)
-
-		if u := cleanF(url); u != "" {
-			urls = append(urls, u)
+		if err == io.EOF {
+			break
 		}
 	}
+	if len(urls) > 0 {
+		windows = append(windows, urls)
+	}
 
-	return urls, prefixes, nil
+	return windows, unmatched, nil
 }
 
-func cleanURL(url string, prefix string) string {
-	return strings.Trim(strings.TrimSpace(strings.TrimPrefix(url, prefix)), "\x00")
-}
+// Byte order mark that some editors write at the start of UTF-8 files
+const utf8BOM = "\ufeff"
 
 var errUserAbort = errors.New("user aborted")
 
-func warnMismatchingPrefixes(prefixes prefixSet, targetPrefix string) bool {
-	// Do not warn and prompt for abort if no mismatch exists
-	if !checkPrefixMismatch(prefixes, targetPrefix) {
+// warnUnmatchedLines warns that lines not matching the prefix and template flags are skipped, returning
+// true if the user aborts
+func warnUnmatchedLines(unmatched []int, opts *tabsOptions) bool {
+	// Do not warn and prompt for abort if every line matches
+	if len(unmatched) == 0 {
 		return false
 	}
 
-	foundPrefix, ok := prefixes.pop()
-	if !ok {
-		return false // Do not abort if a prefix cannot be popped from the set
+	fmt.Fprintf(opts.output, "Warning: skipping %d lines starting with line %d that do not match prefix flag \"%s\" and template flag \"%s\"\n", len(unmatched), unmatched[0], opts.prefix, opts.template)
+
+	return !promptContinue(opts.input, opts.output, "Continue?")
+}
+
+func warnInvalidURLs(windows [][]string, opts *tabsOptions) bool {
+	// Do not warn and prompt for abort if every URL is valid
+	invalid, found := findInvalidURL(windows)
+	if !found {
+		return false
 	}
 
-	if targetPrefix == "" {
-		fmt.Printf("Warning: all URLs contain prefix \"%s\" but prefix flag not provided\n", foundPrefix)
+	if opts.prefix == "" {
+		fmt.Fprintf(opts.output, "Warning: \"%s\" does not appear to be a URL but prefix flag not provided\n", invalid)
 	} else {
-		fmt.Printf("Warning: all URLs contain prefix \"%s\" but prefix flag \"%s\" does not match\n", foundPrefix, targetPrefix)
+		fmt.Fprintf(opts.output, "Warning: \"%s\" does not appear to be a URL, prefix flag \"%s\" or template flag might not match\n", invalid, opts.prefix)
 	}
 
-	var userInput string
-	for userInput != "Y" && userInput != "n" {
-		fmt.Print("Continue? [Y/n]: ")
-		fmt.Scanln(&userInput)
-	}
+	return !promptContinue(opts.input, opts.output, "Continue?")
+}
 
-	return userInput == "n"
+// findInvalidURL returns the first URL that cannot be parsed or contains whitespace, which typically
+// results from a prefix or template that does not match the input
+func findInvalidURL(windows [][]string) (string, bool) {
+	for _, urls := range windows {
+		for _, u := range urls {
+			if _, err := url.Parse(u); err != nil || strings.ContainsAny(u, " \t") {
+				return u, true
+			}
+		}
+	}
+	return "", false
 }
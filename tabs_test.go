@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestOpenTabs(tt *testing.T) {
+	tests := map[string]struct {
+		input       string
+		prefix      string
+		format      string
+		template    string // Defaults to templateURL if empty
+		browserArgs string
+		expected    [][]string // URLs of each new window, in the order opened
+		expectedErr bool
+	}{
+		"newline-delimited URLs": {
+			input:    "https://foo.com\nhttps://bar.com\n",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"URLs with prefix": {
+			input:    "- https://foo.com\n- https://bar.com",
+			prefix:   "- ",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"browser args": {
+			input:       "https://foo.com",
+			browserArgs: "--incognito",
+			expected:    [][]string{{"https://foo.com"}},
+		},
+		"windows separated by blank lines": {
+			input:    "\nhttps://foo.com\nhttps://bar.com\n\nhttps://baz.com\n \n\nhttps://qux.com\n\n",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}, {"https://baz.com"}, {"https://qux.com"}},
+		},
+		"CRLF line endings": {
+			input:    "https://foo.com\r\nhttps://bar.com\r\n\r\nhttps://baz.com\r\n",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}, {"https://baz.com"}},
+		},
+		"UTF-8 byte order mark": {
+			input:    "\ufeffhttps://foo.com\nhttps://bar.com",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"UTF-8 byte order mark with prefix": {
+			input:    "\ufeff- https://foo.com\n- https://bar.com",
+			prefix:   "- ",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"template with name": {
+			input:    "- [foo](https://foo.com)\n- [bar (baz)](https://bar.com/(baz))\n",
+			template: "- [{{.Name}}]({{.URL}})",
+			expected: [][]string{{"https://foo.com", "https://bar.com/(baz)"}},
+		},
+		"template with prefix": {
+			input:    "* [foo](https://foo.com)\n* [bar](https://bar.com)\n",
+			prefix:   "* ",
+			template: "[{{.Name}}]({{.URL}})",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"line not matching template is skipped": {
+			input:    "[foo](https://foo.com)\nhttps://bar.com\n",
+			template: "[{{.Name}}]({{.URL}})",
+			expected: [][]string{{"https://foo.com"}},
+		},
+		"line with wrong prefix is skipped": {
+			input:    "- https://foo.com\n* https://bar.com\n- https://baz.com\n",
+			prefix:   "- ",
+			expected: [][]string{{"https://foo.com", "https://baz.com"}},
+		},
+		"only lines with wrong prefix": {
+			input:       "* https://foo.com\n",
+			prefix:      "- ",
+			expectedErr: true,
+		},
+		"markdown line not matching format": {
+			input:       "- [foo](https://foo.com)\nfoo\n",
+			format:      formatMarkdown,
+			expectedErr: true,
+		},
+		"json lines with windows": {
+			input: `{"url":"https://foo.com","name":"foo","window":1}` + "\n" +
+				`{"url":"https://bar.com","window":2}` + "\n\n" +
+				`{"url":"https://baz.com","window":1}` + "\n",
+			prefix:   "- ",
+			format:   formatJSONL,
+			expected: [][]string{{"https://foo.com", "https://baz.com"}, {"https://bar.com"}},
+		},
+		"json array without windows": {
+			input:    `[{"url":"https://foo.com","name":"\"foo\""}, {"url":"https://bar.com"}]`,
+			format:   formatJSON,
+			template: "[{{.Name}}]({{.URL}})",
+			expected: [][]string{{"https://foo.com", "https://bar.com"}},
+		},
+		"onetab groups": {
+			input:    "https://foo.com | Foo | Bar\r\nhttps://bar.com\n\nhttps://baz.com | \n",
+			prefix:   "- ",
+			format:   formatOneTab,
+			expected: [][]string{{"https://foo.com", "https://bar.com"}, {"https://baz.com"}},
+		},
+		"malformed json record": {
+			input:       `{"url":"https://foo.com"}` + "\n" + `{"url":` + "\n",
+			format:      formatJSONL,
+			expectedErr: true,
+		},
+		"empty json array": {
+			input:       "[]",
+			format:      formatJSON,
+			expectedErr: true,
+		},
+		"no URLs": {
+			input:       "\n\n",
+			expectedErr: true,
+		},
+		"empty input": {
+			input:       "",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			template := test.template
+			if template == "" {
+				template = templateURL
+			}
+
+			driver := newFakeDriver(fakeTabs("https://existing.com"))
+			opts := &tabsOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: defaultMaxTabs,
+					prefix:  test.prefix,
+				},
+				urlReader: &urlReadCloser{
+					Reader: strings.NewReader(test.input),
+					Closer: func() error { return nil },
+				},
+				format:               test.format,
+				template:             template,
+				browserArgs:          test.browserArgs,
+				disablePrefixWarning: true,
+			}
+
+			err := openTabs(opts)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(driver.windows) != len(test.expected)+1 {
+				t.Fatalf("expected %d new windows, found %d windows", len(test.expected), len(driver.windows))
+			}
+			for i, expected := range test.expected {
+				// Each new window is opened in front of the previous windows
+				if result := driver.urls(len(test.expected) - 1 - i); !reflect.DeepEqual(result, expected) {
+					t.Errorf("expected window %d to be %v, result %v", i+1, expected, result)
+				}
+				if result := driver.args[i]; result != test.browserArgs {
+					t.Errorf("expected browser args %q, result %q", test.browserArgs, result)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenTabsUnmatchedLinesWarning(tt *testing.T) {
+	tests := map[string]struct {
+		answer         string
+		expected       []string
+		expectedOutput string
+		expectedErr    error
+	}{
+		"continue": {
+			answer:         "Y\n",
+			expected:       []string{"https://foo.com", "https://baz.com"},
+			expectedOutput: "Warning: skipping 1 lines starting with line 2 that do not match prefix flag \"- \" and template flag \"{{.URL}}\"\nContinue? [Y/n]: ",
+		},
+		"abort": {
+			answer:         "n\n",
+			expected:       []string{"https://existing.com"},
+			expectedOutput: "Warning: skipping 1 lines starting with line 2 that do not match prefix flag \"- \" and template flag \"{{.URL}}\"\nContinue? [Y/n]: ",
+			expectedErr:    errUserAbort,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			driver := newFakeDriver(fakeTabs("https://existing.com"))
+			output := &bytes.Buffer{}
+			opts := &tabsOptions{
+				commonOptions: &commonOptions{
+					driver:  driver,
+					maxTabs: defaultMaxTabs,
+					prefix:  "- ",
+				},
+				urlReader: &urlReadCloser{
+					Reader: strings.NewReader("- https://foo.com\n* https://bar.com\n- https://baz.com\n"),
+					Closer: func() error { return nil },
+				},
+				template: templateURL,
+				input:    strings.NewReader(test.answer),
+				output:   output,
+			}
+
+			err := openTabs(opts)
+			if err != test.expectedErr {
+				t.Fatalf("expected error %v, result %v", test.expectedErr, err)
+			}
+			if result := driver.urls(0); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+			if result := output.String(); result != test.expectedOutput {
+				t.Errorf("expected output %q, result %q", test.expectedOutput, result)
+			}
+		})
+	}
+}
+
+func TestReadURLs(tt *testing.T) {
+	manyURLs := []string{}
+	for i := 0; i < 50000; i++ {
+		manyURLs = append(manyURLs, fmt.Sprintf("https://foo.com/%d/%s", i, strings.Repeat("x", 20)))
+	}
+	longURL := "https://foo.com/" + strings.Repeat("x", 2*1024*1024)
+
+	tests := map[string]struct {
+		reader            io.Reader
+		template          string // Defaults to templateURL if empty
+		expected          [][]string
+		expectedUnmatched []int
+	}{
+		"empty input": {
+			reader:   strings.NewReader(""),
+			expected: [][]string{},
+		},
+		"lines not matching template": {
+			reader:            strings.NewReader("- https://foo.com\n* https://qux.com\n\n- https://bar.com\nhttps://baz.com"),
+			template:          "- " + templateURL,
+			expected:          [][]string{{"https://foo.com"}, {"https://bar.com"}},
+			expectedUnmatched: []int{2, 5},
+		},
+		"lines matching template without URL": {
+			reader:            strings.NewReader("[foo](https://foo.com)\n[bar]()\nhttps://baz.com"),
+			template:          "{{if .Name}}[{{.Name}}]({{.URL}}){{else}}{{.URL}}{{end}}",
+			expected:          [][]string{{"https://foo.com", "https://baz.com"}},
+			expectedUnmatched: []int{2},
+		},
+		"short reads": {
+			reader:   iotest.OneByteReader(strings.NewReader("https://foo.com\nhttps://bar.com\n\nhttps://baz.com")),
+			expected: [][]string{{"https://foo.com", "https://bar.com"}, {"https://baz.com"}},
+		},
+		"input larger than 1 MiB": {
+			reader:   strings.NewReader(strings.Join(manyURLs, "\n")),
+			expected: [][]string{manyURLs},
+		},
+		"line longer than 1 MiB": {
+			reader:   strings.NewReader("https://foo.com\n" + longURL + "\n"),
+			expected: [][]string{{"https://foo.com", longURL}},
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			r := &urlReadCloser{
+				Reader: test.reader,
+				Closer: func() error { return nil },
+			}
+			template := test.template
+			if template == "" {
+				template = templateURL
+			}
+			parseF, err := buildTemplateParseF(template)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, unmatched, err := readURLs(r, parseF)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %d windows, result %d windows", len(test.expected), len(result))
+			}
+			expectedUnmatched := test.expectedUnmatched
+			if expectedUnmatched == nil {
+				expectedUnmatched = []int{}
+			}
+			if !reflect.DeepEqual(unmatched, expectedUnmatched) {
+				t.Errorf("expected unmatched lines %v, result %v", expectedUnmatched, unmatched)
+			}
+		})
+	}
+}
+
+func TestFindInvalidURL(tt *testing.T) {
+	tests := map[string]struct {
+		windows       [][]string
+		expected      string
+		expectedFound bool
+	}{
+		"valid URLs": {
+			windows: [][]string{{"https://foo.com", "about:blank"}, {"foo.com/bar?baz=1"}},
+		},
+		"URL with unmatched prefix": {
+			windows:       [][]string{{"https://foo.com"}, {"- https://bar.com"}},
+			expected:      "- https://bar.com",
+			expectedFound: true,
+		},
+		"URL with unmatched template": {
+			windows:       [][]string{{"[foo](https://foo.com)"}},
+			expected:      "[foo](https://foo.com)",
+			expectedFound: true,
+		},
+		"URL containing whitespace": {
+			windows:       [][]string{{"* foo.com"}},
+			expected:      "* foo.com",
+			expectedFound: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			result, found := findInvalidURL(test.windows)
+			if found != test.expectedFound {
+				t.Fatalf("expected found %t, result %t", test.expectedFound, found)
+			}
+			if result != test.expected {
+				t.Errorf("expected %q, result %q", test.expected, result)
+			}
+		})
+	}
+}
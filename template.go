@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
 const templateURL = "{{.URL}}"
@@ -15,7 +19,7 @@ func buildTemplateWriteF(w io.Writer, tmpl string) (templatedTabInfoWriter, erro
 		tmpl += "\n"
 	}
 
-	t, err := template.New("output").Parse(tmpl)
+	t, err := template.New("output").Funcs(templateFuncs).Parse(tmpl)
 	if err != nil {
 		return nil, err
 	}
@@ -25,3 +29,159 @@ func buildTemplateWriteF(w io.Writer, tmpl string) (templatedTabInfoWriter, erro
 	}
 	return writeF, nil
 }
+
+// templatedTabInfoParser returns the tab written to a line by a template, or false if the line does not
+// match the template
+type templatedTabInfoParser func(line string) (*tabInfo, bool)
+
+// buildTemplateParseF returns a parser for lines written by buildTemplateWriteF with the same template.
+// The template is compiled into a regular expression capturing the URL and name of a tab, so it is
+// limited to writing a single line with fields, functions, comments, and if and with actions, and the
+// URL must be written at least once without functions. A line matching the template without a URL is
+// not matched.
+func buildTemplateParseF(tmpl string) (templatedTabInfoParser, error) {
+	t, err := template.New("input").Funcs(templateFuncs).Parse(strings.TrimSuffix(tmpl, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &templateCompiler{captures: map[string]string{}}
+	pattern, err := c.compile(t.Tree.Root)
+	if err != nil {
+		return nil, err
+	}
+	if !c.capturesField("URL") {
+		return nil, errors.New("template must contain {{.URL}} without functions")
+	}
+
+	// Allow surrounding whitespace as whitespace is trimmed from each URL
+	re, err := regexp.Compile(`^\s*` + pattern + `\s*$`)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := re.SubexpNames()
+
+	parseF := func(line string) (*tabInfo, bool) {
+		match := re.FindStringSubmatchIndex(line)
+		if match == nil {
+			return nil, false
+		}
+		// Each field is read from the first of its capture groups that is part of the match, as a
+		// field written in either branch of an action is captured by a group in each branch
+		values := map[string]string{}
+		for i, groupName := range groupNames {
+			field, isField := c.captures[groupName]
+			if _, found := values[field]; !isField || found || match[2*i] < 0 {
+				continue
+			}
+			values[field] = line[match[2*i]:match[2*i+1]]
+		}
+		tab := &tabInfo{URL: strings.TrimSpace(values["URL"]), Name: values["Name"]}
+		if tab.URL == "" {
+			return nil, false
+		}
+		return tab, true
+	}
+	return parseF, nil
+}
+
+// templateCompiler compiles the nodes of a parsed template into a regular expression
+type templateCompiler struct {
+	captures map[string]string // Names of capture groups mapped to the field each group captures
+}
+
+// capturesField returns true if a field has been compiled into a capture group
+func (c *templateCompiler) capturesField(field string) bool {
+	for _, captured := range c.captures {
+		if captured == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *templateCompiler) compile(node parse.Node) (string, error) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return "", nil
+		}
+		var sb strings.Builder
+		for _, child := range n.Nodes {
+			pattern, err := c.compile(child)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(pattern)
+		}
+		return sb.String(), nil
+	case *parse.TextNode:
+		if strings.ContainsAny(string(n.Text), "\r\n") {
+			return "", errors.New("template must write a single line")
+		}
+		return regexp.QuoteMeta(string(n.Text)), nil
+	case *parse.CommentNode:
+		return "", nil
+	case *parse.ActionNode:
+		field, err := templateField(n.Pipe)
+		if err == nil {
+			return c.compileField(field)
+		}
+		// Output of functions cannot be parsed so it is matched without capturing
+		if len(n.Pipe.Decl) == 0 {
+			return `.*?`, nil
+		}
+		return "", err
+	case *parse.IfNode:
+		return c.compileBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return c.compileBranch(&n.BranchNode)
+	default:
+		return "", fmt.Errorf("unsupported template action %s", node)
+	}
+}
+
+func (c *templateCompiler) compileField(field string) (string, error) {
+	switch field {
+	case "URL", "Name":
+		// Each occurrence of a field is captured by its own group as group names must be unique
+		group := fmt.Sprintf("%s%d", field, len(c.captures))
+		c.captures[group] = field
+		return `(?P<` + group + `>.*?)`, nil
+	case "WindowIndex", "TabIndex":
+		return `\d+`, nil
+	case "Active":
+		return `(?:true|false)`, nil
+	default:
+		return "", fmt.Errorf("unsupported template field %s", field)
+	}
+}
+
+// compileBranch matches either branch of an action since the condition cannot be known from the output
+func (c *templateCompiler) compileBranch(n *parse.BranchNode) (string, error) {
+	if _, err := templateField(n.Pipe); err != nil {
+		return "", err
+	}
+	list, err := c.compile(n.List)
+	if err != nil {
+		return "", err
+	}
+	if n.ElseList == nil {
+		return `(?:` + list + `)?`, nil
+	}
+	elseList, err := c.compile(n.ElseList)
+	if err != nil {
+		return "", err
+	}
+	return `(?:` + list + `|` + elseList + `)`, nil
+}
+
+// templateField returns the name of the tab field that is the only value of a pipeline
+func templateField(pipe *parse.PipeNode) (string, error) {
+	if len(pipe.Decl) == 0 && len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 {
+		if field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) == 1 {
+			return field.Ident[0], nil
+		}
+	}
+	return "", fmt.Errorf("unsupported template pipeline %s", pipe)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBuildTemplateParseF(tt *testing.T) {
+	tests := map[string]struct {
+		template    string
+		line        string
+		expected    *tabInfo
+		expectedOk  bool
+		expectedErr bool
+	}{
+		"default template": {
+			template:   templateURL,
+			line:       "https://foo.com",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"surrounding whitespace": {
+			template:   templateURL,
+			line:       "  https://foo.com \t",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"template with name": {
+			template:   "- [{{.Name}}]({{.URL}})",
+			line:       "- [foo [bar]](https://foo.com/(bar))",
+			expected:   &tabInfo{URL: "https://foo.com/(bar)", Name: "foo [bar]"},
+			expectedOk: true,
+		},
+		"template with trailing newline": {
+			template:   "{{.Name}}: {{.URL}}\n",
+			line:       "foo: https://foo.com",
+			expected:   &tabInfo{URL: "https://foo.com", Name: "foo"},
+			expectedOk: true,
+		},
+		"template with regular expression characters": {
+			template:   "^{{.URL}}$ (.*)",
+			line:       "^https://foo.com$ (.*)",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"template with window and tab indices and active tab": {
+			template:   "{{.WindowIndex}}.{{.TabIndex}} {{if .Active}}*{{end}}{{.URL}}",
+			line:       "1.2 *https://foo.com",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"template with if else and comment": {
+			template:   "{{/* comment */}}{{if .Active}}[x]{{else}}[ ]{{end}} {{.URL}} {{.Active}}",
+			line:       "[ ] https://foo.com false",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"repeated field": {
+			template:   "{{.URL}} {{.URL}}",
+			line:       "https://foo.com https://foo.com",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"line not matching template": {
+			template:   "[{{.Name}}]({{.URL}})",
+			line:       "https://foo.com",
+			expectedOk: false,
+		},
+		"line not matching field": {
+			template:   "{{.TabIndex}} {{.URL}}",
+			line:       "x https://foo.com",
+			expectedOk: false,
+		},
+		"template without URL": {
+			template:    "{{.Name}}",
+			expectedErr: true,
+		},
+		"multiline template": {
+			template:    "{{.Name}}\n{{.URL}}",
+			expectedErr: true,
+		},
+		"unknown field": {
+			template:    "{{.Foo}} {{.URL}}",
+			expectedErr: true,
+		},
+		"template with functions not writing URL": {
+			template:   "- [{{.Name | trunc 5 | mdEscape}}]({{.URL}}) {{now \"2006-01-02\"}}",
+			line:       "- [\\[foo\\]](https://foo.com) 2024-01-01",
+			expected:   &tabInfo{URL: "https://foo.com"},
+			expectedOk: true,
+		},
+		"template with function writing URL": {
+			template:    "{{printf \"%s\" .URL}}",
+			expectedErr: true,
+		},
+		"template with piped URL": {
+			template:    "{{.Name}} {{.URL | host}}",
+			expectedErr: true,
+		},
+		"invalid template": {
+			template:    "{{.URL",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			parseF, err := buildTemplateParseF(test.template)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, ok := parseF(test.line)
+			if ok != test.expectedOk {
+				t.Fatalf("expected ok %t, result %t", test.expectedOk, ok)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %v, result %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestTemplateRoundTrip(tt *testing.T) {
+	tabs := []*tabInfo{
+		{URL: "https://foo.com", Name: "foo", WindowIndex: 1, TabIndex: 1, Active: true},
+		{URL: "https://bar.com/?q=a+b", Name: "bar: (baz) [qux]", WindowIndex: 1, TabIndex: 2},
+		{URL: "https://baz.com", WindowIndex: 1, TabIndex: 3},
+	}
+
+	templates := []string{
+		templateURL,
+		"- {{.URL}}",
+		"[{{.Name}}]({{.URL}})",
+		"{{.Name}}\t{{.URL}}",
+		"{{.WindowIndex}}.{{.TabIndex}} {{if .Active}}*{{end}}{{.URL}} {{.Name}}",
+		"- [{{.Name | mdEscape}}]({{.URL}}) ({{.URL | domain}})",
+		"{{if .Name}}[{{.Name}}]({{.URL}}){{else}}{{.URL}}{{end}}",
+	}
+
+	for _, tmpl := range templates {
+		tt.Run(tmpl, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			writeF, err := buildTemplateWriteF(buf, tmpl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			parseF, err := buildTemplateParseF(tmpl)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, tab := range tabs {
+				buf.Reset()
+				if err := writeF(tab); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				result, ok := parseF(buf.String())
+				if !ok {
+					t.Fatalf("expected %q to match template", buf.String())
+				}
+				if result.URL != tab.URL {
+					t.Errorf("expected URL %q, result %q", tab.URL, result.URL)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+func runUndoCmd(cmd *flag.FlagSet, args []string) error {
+	opts, err := parseUndoFlags(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	err = undoClose(opts)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type undoOptions struct {
+	*commonOptions
+	journal     *closeJournal
+	browserArgs string
+}
+
+func parseUndoFlags(fs *flag.FlagSet, args []string) (*undoOptions, error) {
+	attachCommonFlags(fs)
+
+	var (
+		browserArgs = fs.String(
+			"browser-args",
+			setStringFlagDefault("", envVarBrowserArgs),
+			"optional space-delimited arguments to be passed to the browser",
+		)
+	)
+
+	defaultUsage := fs.Usage
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "`%s` %s\n\n", undoCmdName, undoCmdDescription)
+		defaultUsage()
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := parseCommonOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := newCloseJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &undoOptions{
+		commonOptions: commonOpts,
+		journal:       journal,
+		browserArgs:   *browserArgs,
+	}
+	return opts, nil
+}
+
+// undoClose reopens the most recently closed batch of tabs in their original order, removing the batch
+// from the journal only once the tabs are open so that a failed undo can be retried
+func undoClose(opts *undoOptions) error {
+	batch, err := opts.journal.last()
+	if err != nil {
+		return err
+	}
+
+	tabs := append([]*tabInfo{}, batch.Tabs...)
+	sort.SliceStable(tabs, func(i, j int) bool {
+		if tabs[i].WindowIndex != tabs[j].WindowIndex {
+			return tabs[i].WindowIndex < tabs[j].WindowIndex
+		}
+		return tabs[i].TabIndex < tabs[j].TabIndex
+	})
+	data, err := json.Marshal(tabs)
+	if err != nil {
+		return fmt.Errorf("failed to encode closed tabs: %w", err)
+	}
+
+	err = openTabs(&tabsOptions{
+		commonOptions: opts.commonOptions,
+		urlReader:     io.NopCloser(bytes.NewReader(data)),
+		format:        formatJSON,
+		browserArgs:   opts.browserArgs,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = opts.journal.removeLast()
+	if err != nil {
+		return fmt.Errorf("failed to remove reopened tabs from journal: %w", err)
+	}
+	return nil
+}
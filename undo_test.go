@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUndoClose(t *testing.T) {
+	journal := &closeJournal{path: filepath.Join(t.TempDir(), journalFileName)}
+	driver := newFakeDriver(fakeTabs("https://foo.com", "https://bar.com/1", "https://baz.com", "https://bar.com/2"))
+	commonOpts := &commonOptions{
+		driver:  driver,
+		maxTabs: defaultMaxTabs,
+	}
+
+	closeBar := &closeOptions{
+		commonOptions: commonOpts,
+		matcher:       newSubstringMatcher([]string{"bar"}, nil),
+		journal:       journal,
+	}
+	if err := closeTabs(closeBar); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closeFoo := &closeOptions{
+		commonOptions: commonOpts,
+		matcher:       newSubstringMatcher([]string{"foo"}, nil),
+		journal:       journal,
+	}
+	if err := closeTabs(closeFoo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Dry runs are not recorded
+	dryRun := &closeOptions{
+		commonOptions: commonOpts,
+		matcher:       newSubstringMatcher([]string{"baz"}, nil),
+		journal:       journal,
+		dryRun:        true,
+		output:        io.Discard,
+	}
+	if err := closeTabs(dryRun); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := &undoOptions{
+		commonOptions: commonOpts,
+		journal:       journal,
+		browserArgs:   "--incognito",
+	}
+
+	// Each undo reopens the most recently closed tabs in a new window
+	expected := [][]string{
+		{"https://foo.com"},
+		{"https://bar.com/1", "https://bar.com/2"},
+	}
+	for _, urls := range expected {
+		if err := undoClose(opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := driver.urls(0); !reflect.DeepEqual(result, urls) {
+			t.Errorf("expected %v, result %v", urls, result)
+		}
+	}
+	if result := driver.args; !reflect.DeepEqual(result, []string{"--incognito", "--incognito"}) {
+		t.Errorf("expected browser args for each undo, result %v", result)
+	}
+
+	if err := undoClose(opts); err != errEmptyJournal {
+		t.Errorf("expected error %v, result %v", errEmptyJournal, err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	envVarXDGStateHome = "XDG_STATE_HOME"
+	envVarXDGDataHome  = "XDG_DATA_HOME"
+)
+
+// xdgStateDir returns the user's XDG state directory, defaulting to ~/.local/state
+func xdgStateDir() (string, error) {
+	return xdgDir(envVarXDGStateHome, ".local", "state")
+}
+
+// xdgDataDir returns the user's XDG data directory, defaulting to ~/.local/share
+func xdgDataDir() (string, error) {
+	return xdgDir(envVarXDGDataHome, ".local", "share")
+}
+
+// xdgDir returns the directory set by an XDG environment variable, or the default path relative to the
+// home directory if the variable is not set to an absolute path as required by the specification
+func xdgDir(envVar string, defaultPath ...string) (string, error) {
+	if dir := os.Getenv(envVar); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory for %s: %w", envVar, err)
+	}
+	return filepath.Join(append([]string{home}, defaultPath...)...), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestXDGDir(tt *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		tt.Skip("no home directory")
+	}
+
+	tests := map[string]struct {
+		envVar   string
+		env      string
+		dirF     func() (string, error)
+		expected string
+	}{
+		"state from environment": {
+			envVar:   envVarXDGStateHome,
+			env:      "/tmp/state",
+			dirF:     xdgStateDir,
+			expected: "/tmp/state",
+		},
+		"default state": {
+			envVar:   envVarXDGStateHome,
+			env:      "",
+			dirF:     xdgStateDir,
+			expected: filepath.Join(home, ".local", "state"),
+		},
+		"relative state path is ignored": {
+			envVar:   envVarXDGStateHome,
+			env:      "state",
+			dirF:     xdgStateDir,
+			expected: filepath.Join(home, ".local", "state"),
+		},
+		"data from environment": {
+			envVar:   envVarXDGDataHome,
+			env:      "/tmp/data",
+			dirF:     xdgDataDir,
+			expected: "/tmp/data",
+		},
+		"default data": {
+			envVar:   envVarXDGDataHome,
+			env:      "",
+			dirF:     xdgDataDir,
+			expected: filepath.Join(home, ".local", "share"),
+		},
+	}
+
+	for name, test := range tests {
+		tt.Run(name, func(t *testing.T) {
+			t.Setenv(test.envVar, test.env)
+			result, err := test.dirF()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != test.expected {
+				t.Errorf("expected %s, result %s", test.expected, result)
+			}
+		})
+	}
+}